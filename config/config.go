@@ -2,7 +2,14 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Config struct {
@@ -17,6 +24,12 @@ type Config struct {
 
 	// Email configuration
 	Email EmailConfig
+
+	// Rate limit configuration
+	RateLimit RateLimitConfig
+
+	// CORS configuration
+	CORS CORSConfig
 }
 
 type DBConfig struct {
@@ -25,12 +38,66 @@ type DBConfig struct {
 	User     string
 	Password string
 	Name     string
+
+	// 啟動時連線重試：等待時間從 ConnectRetryBase 開始指數成長，最多 ConnectRetryMax，
+	// 總共最多嘗試 ConnectMaxAttempts 次（0 表示不限次數）且不超過 ConnectTimeout
+	ConnectRetryBase   time.Duration
+	ConnectRetryMax    time.Duration
+	ConnectTimeout     time.Duration
+	ConnectMaxAttempts int
+
+	// 連線池設定：MaxOpenConns 乘上 app instance 數量必須小於 MySQL 的 max_connections（預設 151），
+	// 並保留空間給 migrate 與管理工具；ConnMaxLifetime 應短於 MySQL 的 wait_timeout
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// 啟動時自動套用內嵌的 migrations
+	AutoMigrate bool
 }
 
 type ServerConfig struct {
 	Port       string
 	JWTSecret  string
 	FrontendOrigin string
+	// Access token（JWT）有效時間，JWT_TTL 例如 "24h"
+	AccessTokenTTL time.Duration
+	// 嚴格模式會拒絕沒有 exp 的 JWT
+	JWTStrict bool
+	// 驗證 exp / nbf / iat 時容許的時鐘誤差
+	JWTLeeway time.Duration
+	// 同一帳號在 LoginFailureWindow 內登入失敗達 LoginMaxFailures 次即暫停登入
+	LoginMaxFailures   int
+	LoginFailureWindow time.Duration
+	// 產生密碼雜湊的 bcrypt cost，必須介於 bcrypt.MinCost 與 bcrypt.MaxCost 之間
+	BcryptCost int
+	// 同一帳號在此時間內只會建立一次重設密碼 token，避免重複寄信（0 代表不限制）
+	PasswordResetThrottle time.Duration
+	// 同一帳號在此時間內只會重寄一次驗證信（0 代表不限制）
+	EmailVerificationThrottle time.Duration
+	// 解壓縮後請求內容的上限（bytes）
+	MaxDecompressedBodyBytes int64
+	// 請求內容（未解壓縮）的上限（bytes），批次與匯入端點使用 MaxBulkBodyBytes
+	MaxBodyBytes     int64
+	MaxBulkBodyBytes int64
+	// API 掛載的路徑前綴，API_BASE_PATH 例如 "/todo/api/v1"
+	APIBasePath string
+	// 需登入的 GET 端點允許的私有快取時間，0 代表 no-store
+	CacheMaxAge time.Duration
+	// 請求 log 的格式：text（預設，方便本機閱讀）或 json
+	LogFormat string
+	// 背景清除過期重設密碼 token 與垃圾桶中過期任務的間隔
+	CleanupInterval time.Duration
+	// 每個請求的處理期限，超過時取消 DB 查詢並回傳 503（0 代表不限制）
+	RequestTimeout time.Duration
+	// 執行環境，APP_ENV 例如 "development"、"production"
+	AppEnv string
+	// 開啟 /dev/* 等僅供開發測試的端點（APP_ENV=development 時自動開啟）
+	EnableDevEndpoints bool
+	// 信任的反向代理 IP 或 CIDR，只有來自這些位址的請求才會採用 X-Forwarded-For（預設不信任任何代理）
+	TrustedProxies []string
+	// 任務標籤一律去除空白並轉為小寫，避免 "Work" 與 "work" 成為兩個標籤；開啟時啟動會先合併既有的重複標籤
+	NormalizeLabels bool
 }
 
 type SwaggerConfig struct {
@@ -45,6 +112,33 @@ type EmailConfig struct {
 	SMTPPassword string
 	FromEmail    string
 	FromName     string
+	// 信件中連結的前端網址（重設密碼與 email 驗證共用），例如 https://app.example.com
+	ResetURLBase string
+	// UseTLS 為 true 時先以 STARTTLS 建立加密連線再進行認證
+	UseTLS bool
+	// InsecureSkipVerify 略過伺服器憑證驗證，僅供測試環境使用
+	InsecureSkipVerify bool
+}
+
+// RateLimitConfig 為全域與每個用戶端 IP 的請求頻率限制
+type RateLimitConfig struct {
+	// 所有請求合計每秒允許的請求數與突發上限（預設 100 / 200，適合小型網站 100-500 用戶）
+	GlobalRPS   float64
+	GlobalBurst int
+	// 每個 IP 每秒允許的請求數與突發上限
+	RPS   float64
+	Burst int
+	// IP 閒置超過此時間即移除其限制器
+	IdleTTL time.Duration
+	// 不受頻率限制的 IP 或 CIDR（例如監控服務、內部服務）
+	Whitelist []*net.IPNet
+}
+
+// CORSConfig 為 CORS 回應允許的方法、標頭與 preflight 快取時間
+type CORSConfig struct {
+	AllowedMethods []string
+	AllowedHeaders []string
+	MaxAge         time.Duration
 }
 
 func LoadConfig() *Config {
@@ -55,11 +149,42 @@ func LoadConfig() *Config {
 			User:     getEnv("DB_USER", "root"),
 			Password: getEnv("DB_PASSWORD", ""),
 			Name:     getEnv("DB_NAME", "app_db"),
+
+			ConnectRetryBase:   getEnvDuration("DB_CONNECT_RETRY_BASE", 500*time.Millisecond),
+			ConnectRetryMax:    getEnvDuration("DB_CONNECT_RETRY_MAX", 10*time.Second),
+			ConnectTimeout:     getEnvDuration("DB_CONNECT_TIMEOUT", 60*time.Second),
+			ConnectMaxAttempts: int(getEnvInt64("DB_CONNECT_MAX_ATTEMPTS", 0)),
+
+			MaxOpenConns:    int(getEnvInt64("DB_MAX_OPEN_CONNS", 25)),
+			MaxIdleConns:    int(getEnvInt64("DB_MAX_IDLE_CONNS", 5)),
+			ConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+
+			AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", true),
 		},
 		Server: ServerConfig{
 			Port:       getEnv("PORT", "8088"),
 			JWTSecret:  getEnv("JWT_SECRET", ""),
 			FrontendOrigin: getEnv("FRONTEND_ORIGIN", ""),
+			AccessTokenTTL: getEnvDuration("JWT_TTL", 72*time.Hour),
+			JWTStrict:      getEnvBool("JWT_STRICT", true),
+			JWTLeeway:      getEnvDurationAllowZero("JWT_LEEWAY", 30*time.Second),
+			LoginMaxFailures:   int(getEnvInt64("LOGIN_MAX_FAILURES", 5)),
+			LoginFailureWindow: getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+			PasswordResetThrottle: getEnvDurationAllowZero("PASSWORD_RESET_THROTTLE", 2*time.Minute),
+			EmailVerificationThrottle: getEnvDurationAllowZero("EMAIL_VERIFICATION_THROTTLE", 2*time.Minute),
+			BcryptCost:            getEnvBcryptCost("BCRYPT_COST"),
+			MaxDecompressedBodyBytes: getEnvInt64("MAX_DECOMPRESSED_BODY_BYTES", 10<<20),
+			MaxBodyBytes:     getEnvInt64("MAX_BODY_BYTES", 1<<20),
+			MaxBulkBodyBytes: getEnvInt64("MAX_BULK_BODY_BYTES", 10<<20),
+			APIBasePath: normalizeBasePath(getEnv("API_BASE_PATH", "/api/v1")),
+			CacheMaxAge: getEnvDurationAllowZero("CACHE_MAX_AGE", 0),
+			LogFormat:   strings.ToLower(getEnv("LOG_FORMAT", "text")),
+			CleanupInterval: getEnvDuration("CLEANUP_INTERVAL", time.Hour),
+			RequestTimeout:  getEnvDurationAllowZero("REQUEST_TIMEOUT", 10*time.Second),
+			AppEnv:             strings.ToLower(getEnv("APP_ENV", "production")),
+			EnableDevEndpoints: getEnvBool("ENABLE_DEV_ENDPOINTS", false),
+			TrustedProxies:     getEnvList("TRUSTED_PROXIES", nil),
+			NormalizeLabels:    getEnvBool("NORMALIZE_LABELS", false),
 		},
 		Swagger: SwaggerConfig{
 			Host:   getEnv("SWAGGER_HOST", "localhost:8088"),
@@ -72,6 +197,22 @@ func LoadConfig() *Config {
 			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
 			FromEmail:    getEnv("FROM_EMAIL", ""),
 			FromName:     getEnv("FROM_NAME", ""),
+			ResetURLBase: getEnv("RESET_URL_BASE", "http://localhost:3000"),
+			UseTLS:             getEnvBool("SMTP_USE_TLS", false),
+			InsecureSkipVerify: getEnvBool("SMTP_INSECURE_SKIP_VERIFY", false),
+		},
+		RateLimit: RateLimitConfig{
+			GlobalRPS:   getEnvFloat("RATE_LIMIT_GLOBAL_RPS", 100),
+			GlobalBurst: int(getEnvInt64("RATE_LIMIT_GLOBAL_BURST", 200)),
+			RPS:         getEnvFloat("RATE_LIMIT_RPS", 10),
+			Burst:       int(getEnvInt64("RATE_LIMIT_BURST", 20)),
+			IdleTTL:     getEnvDuration("RATE_LIMIT_IDLE_TTL", 10*time.Minute),
+			Whitelist:   getEnvCIDRs("RATE_LIMIT_WHITELIST"),
+		},
+		CORS: CORSConfig{
+			AllowedMethods: getEnvList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowedHeaders: getEnvList("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization"}),
+			MaxAge:         getEnvDuration("CORS_MAX_AGE", 10*time.Minute),
 		},
 	}
 }
@@ -86,4 +227,139 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s=%q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvBcryptCost 讀取 bcrypt cost，未設定或超出 bcrypt 允許範圍時使用 bcrypt.DefaultCost
+func getEnvBcryptCost(key string) int {
+	cost := int(getEnvInt64(key, int64(bcrypt.DefaultCost)))
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		log.Printf("⚠️ Invalid %s=%d (must be %d-%d), using default %d", key, cost, bcrypt.MinCost, bcrypt.MaxCost, bcrypt.DefaultCost)
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed <= 0 {
+		log.Printf("⚠️ Invalid %s=%q, using default %g", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("⚠️ Invalid %s=%q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvDurationAllowZero 與 getEnvDuration 相同，但接受 0（用於 0 代表不限制或關閉的設定），只拒絕負值
+func getEnvDurationAllowZero(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		log.Printf("⚠️ Invalid %s=%q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvList 讀取以逗號分隔的清單，會去除空白與空項目
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		log.Printf("⚠️ Invalid %s=%q, using default %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return items
+}
+
+// getEnvCIDRs 解析以逗號分隔的 IP 或 CIDR 清單，單一 IP 視為 /32（IPv6 為 /128），無效的項目記錄警告後略過
+func getEnvCIDRs(key string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, item := range getEnvList(key, nil) {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				log.Printf("⚠️ Invalid IP %q in %s, skipping", item, key)
+				continue
+			}
+			if ip.To4() != nil {
+				item += "/32"
+			} else {
+				item += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			log.Printf("⚠️ Invalid CIDR %q in %s, skipping", item, key)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// DevEndpointsEnabled 回傳是否註冊僅供開發測試的端點，必須明確設定 APP_ENV=development 或 ENABLE_DEV_ENDPOINTS=true
+func (s ServerConfig) DevEndpointsEnabled() bool {
+	return s.AppEnv == "development" || s.EnableDevEndpoints
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s=%q, using default %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// normalizeBasePath 確保路徑前綴以 / 開頭且結尾沒有 /，空字串代表掛在根目錄
+func normalizeBasePath(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
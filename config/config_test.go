@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZeroDurationsDisableLimits(t *testing.T) {
+	t.Setenv("REQUEST_TIMEOUT", "0")
+	t.Setenv("PASSWORD_RESET_THROTTLE", "0s")
+	t.Setenv("JWT_LEEWAY", "0")
+
+	server := LoadConfig().Server
+	if server.RequestTimeout != 0 {
+		t.Fatalf("expected REQUEST_TIMEOUT=0 to disable the timeout, got %s", server.RequestTimeout)
+	}
+	if server.PasswordResetThrottle != 0 {
+		t.Fatalf("expected PASSWORD_RESET_THROTTLE=0 to disable throttling, got %s", server.PasswordResetThrottle)
+	}
+	if server.JWTLeeway != 0 {
+		t.Fatalf("expected JWT_LEEWAY=0 to disable leeway, got %s", server.JWTLeeway)
+	}
+}
+
+func TestNegativeDurationFallsBackToDefault(t *testing.T) {
+	t.Setenv("REQUEST_TIMEOUT", "-5s")
+
+	if got := LoadConfig().Server.RequestTimeout; got != 10*time.Second {
+		t.Fatalf("expected the 10s default for a negative value, got %s", got)
+	}
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// WithTransaction 開啟 transaction 執行 fn：fn 回傳 nil 時 commit，回傳錯誤或 panic 時 rollback。
+// panic 會被攔截並轉成錯誤回傳，呼叫端不需要再自行處理 Rollback。
+func WithTransaction(database *sql.DB, fn func(transaction *sql.Tx) error) (err error) {
+	transaction, err := database.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			transaction.Rollback()
+			err = fmt.Errorf("transaction panicked: %v", recovered)
+		}
+	}()
+
+	if err := fn(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+	if err := transaction.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/dev/latest-token": {
             "get": {
-                "description": "返回最新的未使用密碼重設 token，僅供開發環境測試使用",
+                "description": "返回最新的未使用密碼重設 token，僅供開發環境測試使用；只有設定 APP_ENV=development 或 ENABLE_DEV_ENDPOINTS=true 時才會註冊",
                 "produces": [
                     "application/json"
                 ],
@@ -25,6 +25,7 @@ const docTemplate = `{
                     "Auth"
                 ],
                 "summary": "獲取最新的重設密碼 token (僅供開發測試)",
+                "operationId": "getLatestToken",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -38,10 +39,7 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
@@ -49,7 +47,7 @@ const docTemplate = `{
         },
         "/forgot-password": {
             "post": {
-                "description": "發送重設密碼信件到用戶 email",
+                "description": "發送重設密碼信件到用戶 email。不論 email 是否已註冊都回傳相同的 200 訊息，只有帳號存在時才會寄信；\n同一帳號在 PASSWORD_RESET_THROTTLE（預設 2 分鐘）內重複請求時不會再寄信",
                 "consumes": [
                     "application/json"
                 ],
@@ -60,6 +58,7 @@ const docTemplate = `{
                     "Auth"
                 ],
                 "summary": "忘記密碼",
+                "operationId": "forgotPassword",
                 "parameters": [
                     {
                         "description": "Email 地址",
@@ -89,19 +88,13 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
@@ -109,7 +102,7 @@ const docTemplate = `{
         },
         "/login": {
             "post": {
-                "description": "輸入 email 與密碼後登入並取得 JWT Token",
+                "description": "輸入 email 與密碼後登入並取得 JWT Token 與 refresh token",
                 "consumes": [
                     "application/json"
                 ],
@@ -120,6 +113,7 @@ const docTemplate = `{
                     "Auth"
                 ],
                 "summary": "使用者登入",
+                "operationId": "login",
                 "parameters": [
                     {
                         "description": "登入資訊",
@@ -144,58 +138,44 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
-                    }
-                }
-            }
-        },
-        "/plans/sections": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "依照排序列出所有區塊",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Plans"
-                ],
-                "summary": "取得所有區塊（Section）",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Section"
-                            }
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/plans/batch": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "建立一個新的區塊（自動補上 sort_order）",
+                "description": "依序執行 create/update/delete/move 操作（section 或 task），全部在同一個 transaction 中完成，任一操作失敗即全部回滾。\n建立操作可帶 temp_id，之後的操作可用 ref / section_ref 參照同一批次中建立的資源。",
                 "consumes": [
                     "application/json"
                 ],
@@ -205,15 +185,16 @@ const docTemplate = `{
                 "tags": [
                     "Plans"
                 ],
-                "summary": "建立新區塊（Section）",
+                "summary": "批次執行區塊與任務操作",
+                "operationId": "executeBatch",
                 "parameters": [
                     {
-                        "description": "區塊資料",
-                        "name": "section",
+                        "description": "批次操作",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateSectionInput"
+                            "$ref": "#/definitions/models.BatchInput"
                         }
                     }
                 ],
@@ -221,71 +202,82 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.BatchResult"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.BatchError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.BatchError"
                         }
                     }
                 }
             }
         },
-        "/plans/sections-with-tasks": {
+        "/plans/export": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "回傳每個區塊與其所屬任務（僅限本人），依照排序排列",
+                "description": "以 JSON 檔下載本人所有區塊（含已封存）與任務（含延後中，不含已刪除），格式附上 version 供日後匯入時轉換；沒有任何區塊時 sections 為空陣列",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Plans"
                 ],
-                "summary": "取得所有區塊（含任務）",
+                "summary": "匯出計畫",
+                "operationId": "exportPlans",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.SectionWithTasks"
-                            }
+                            "$ref": "#/definitions/models.PlanExport"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/plans/import": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "依據傳入資料更新 sections 與 tasks 的 sort_order（title/content 不會變動）",
+                "description": "以 /plans/export 的格式還原備份，在同一個 transaction 中建立新的區塊與任務（使用新的 ID，sort_order 依檔案順序重新編號）。\n預設 append 會接在現有區塊之後；mode=replace 會先刪除本人所有區塊與任務再匯入。version 不是目前支援的版本時回傳 400",
                 "consumes": [
                     "application/json"
                 ],
@@ -295,96 +287,109 @@ const docTemplate = `{
                 "tags": [
                     "Plans"
                 ],
-                "summary": "批次更新區塊與任務排序",
+                "summary": "匯入計畫",
+                "operationId": "importPlans",
                 "parameters": [
                     {
-                        "description": "排序資料",
+                        "enum": [
+                            "append",
+                            "replace"
+                        ],
+                        "type": "string",
+                        "description": "append（預設）或 replace",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "description": "匯出的備份內容",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.SectionWithTasks"
-                            }
+                            "$ref": "#/definitions/models.PlanExport"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.PlanImportResult"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
             }
         },
-        "/plans/sections/{id}": {
-            "put": {
+        "/plans/next": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據 ID 修改區塊的標題，僅限本人操作",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "回傳本人單一個最該先做的未完成任務：優先度由高到低，再依到期日由近到遠（沒有到期日的排最後），最後依排序；\n篩選規則與 GET /plans/tasks/today 相同，預設排除封存區塊中的任務與延後中的任務。沒有待辦任務時回傳 204",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Plans"
                 ],
-                "summary": "更新區塊（Section 標題）",
+                "summary": "取得下一個要做的任務（專注模式）",
+                "operationId": "getNextTask",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Section ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "只包含這些區塊 ID，逗號分隔（最多 100 個）",
+                        "name": "section_ids",
+                        "in": "query"
                     },
                     {
-                        "description": "更新資料",
-                        "name": "section",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UpdateSectionInput"
-                        }
+                        "type": "string",
+                        "description": "只考慮帶有此標籤的任務",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含仍在延後中的任務（預設不包含）",
+                        "name": "include_deferred",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "未帶 section_ids 時包含封存區塊中的任務（預設不包含）",
+                        "name": "include_archived",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.Task"
                         }
                     },
+                    "204": {
+                        "description": "No Content"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -394,34 +399,46 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/plans/search": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據 ID 刪除一個區塊，並重新排序該使用者的其他區塊",
+                "description": "以關鍵字搜尋本人的任務標題與內容（不分大小寫的部分比對），回傳時附上所屬區塊標題；已刪除的任務與封存區塊中的任務不會出現。依更新時間由新到舊排序，最多 100 筆",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Plans"
                 ],
-                "summary": "刪除區塊（Section）",
+                "summary": "搜尋任務",
+                "operationId": "searchTasks",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Section ID",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "關鍵字（至少 2 個字元）",
+                        "name": "q",
+                        "in": "query",
                         "required": true
                     }
                 ],
@@ -429,90 +446,100 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TaskSearchResult"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
             }
         },
-        "/plans/tasks": {
-            "post": {
+        "/plans/sections": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "建立新的任務，並自動排序",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "依照排序列出所有區塊，預設不含已封存的區塊；可用 fields 只回傳指定欄位",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Plans"
                 ],
-                "summary": "建立任務（Task）",
+                "summary": "取得所有區塊（Section）",
+                "operationId": "listSections",
                 "parameters": [
                     {
-                        "description": "任務內容",
-                        "name": "task",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateTaskInput"
-                        }
+                        "type": "string",
+                        "description": "只回傳的欄位，逗號分隔（例如 id,title）",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含已封存的區塊（預設不包含）",
+                        "name": "include_archived",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只包含建立時間不早於此時間的區塊（RFC 3339）",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只包含建立時間不晚於此時間的區塊（RFC 3339），可只指定 from 或 to 其中一個",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Section"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
-            }
-        },
-        "/plans/tasks/{id}": {
-            "put": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據 ID 更新任務內容",
+                "description": "建立一個新的區塊（自動補上 sort_order），回傳格式與列表中的元素相同；有帶 client_id 時會一併回傳",
                 "consumes": [
                     "application/json"
                 ],
@@ -522,82 +549,214 @@ const docTemplate = `{
                 "tags": [
                     "Plans"
                 ],
-                "summary": "更新任務（Task）",
+                "summary": "建立新區塊（Section）",
+                "operationId": "createSection",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "任務 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "更新資料",
-                        "name": "task",
+                        "description": "區塊資料",
+                        "name": "section",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateTaskInput"
+                            "$ref": "#/definitions/models.CreateSectionInput"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/models.Section"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "新區塊的 URL"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "只以 COUNT(*) 計算本人的區塊數（預設不含已封存的區塊），透過 X-Total-Count header 回傳，不含內容",
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得區塊數量（HEAD）",
+                "operationId": "countSections",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "包含已封存的區塊（預設不包含）",
+                        "name": "include_archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "X-Total-Count header",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "區塊總數"
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/plans/sections-with-tasks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "回傳每個區塊與其所屬任務（僅限本人），依照排序排列，預設不含已封存的區塊；可依完成狀態、優先度、標籤與到期日篩選內嵌的任務",
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得所有區塊（含任務）",
+                "operationId": "listSectionsWithTasks",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "包含已封存的區塊（預設不包含）",
+                        "name": "include_archived",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只包含已完成（true）或未完成（false）的任務",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "low",
+                            "medium",
+                            "high"
+                        ],
+                        "type": "string",
+                        "description": "只包含指定優先度的任務",
+                        "name": "priority",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sort_order",
+                            "priority"
+                        ],
+                        "type": "string",
+                        "description": "區塊內任務的排序：sort_order（預設）或 priority（高到低，再依 sort_order）",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只包含帶有此標籤的任務",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只包含到期日早於此時間（RFC 3339）的任務",
+                        "name": "due_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只回傳篩選後仍有任務的區塊",
+                        "name": "only_nonempty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含仍在延後中的任務（預設不包含）",
+                        "name": "include_deferred",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SectionWithTasks"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
             },
-            "delete": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據 ID 刪除任務，並重新排序同區塊內的任務",
+                "description": "依據傳入資料更新 sections 與 tasks 的 sort_order（title/content 不會變動）。\n每個 section 與 task 都必須帶上最後讀到的 version；任一筆版本不符代表資料已被其他分頁或裝置修改，整批不會寫入並回傳 409，前端應重新讀取後再送出",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Plans"
                 ],
-                "summary": "刪除任務（Task）",
+                "summary": "批次更新區塊與任務排序",
+                "operationId": "updateSectionsWithTasks",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "任務 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "排序資料",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SectionWithTasks"
+                            }
+                        }
                     }
                 ],
                 "responses": {
@@ -613,117 +772,145 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
             }
         },
-        "/profile": {
-            "get": {
+        "/plans/sections/bulk": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "使用 JWT 取得當前登入者資訊",
+                "description": "一次建立多個區塊，依陣列順序接在使用者現有區塊之後，全部在同一個 transaction 中完成，任一筆失敗即全部回滾（單次最多 100 筆）",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "Plans"
+                ],
+                "summary": "批次建立區塊（Section）",
+                "operationId": "bulkCreateSections",
+                "parameters": [
+                    {
+                        "description": "區塊資料",
+                        "name": "sections",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CreateSectionInput"
+                            }
+                        }
+                    }
                 ],
-                "summary": "取得個人資訊",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Section"
+                            }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
             }
         },
-        "/register": {
-            "post": {
-                "description": "使用者註冊帳號",
-                "consumes": [
-                    "application/json"
+        "/plans/sections/recent": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "依區塊本身與其任務中最新的 updated_at 由新到舊排序，回傳使用者最近操作過的區塊（不含已封存的區塊）",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Plans"
                 ],
-                "summary": "註冊使用者",
+                "summary": "取得最近活動的區塊",
+                "operationId": "listRecentSections",
                 "parameters": [
                     {
-                        "description": "使用者資料",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UserRegisterInput"
-                        }
+                        "type": "integer",
+                        "description": "筆數（預設 10，最多 50）",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RecentSection"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
             }
         },
-        "/reset-password": {
-            "post": {
-                "description": "使用 token 重設用戶密碼",
+        "/plans/sections/reorder": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "依陣列順序更新區塊的 sort_order，只排序區塊、不動任務。陣列必須剛好包含本人所有未封存的區塊且不可重複，否則整批不會寫入；已封存的區塊依原本順序排在最後",
                 "consumes": [
                     "application/json"
                 ],
@@ -731,25 +918,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Plans"
                 ],
-                "summary": "重設密碼",
+                "summary": "重新排序區塊",
+                "operationId": "reorderSections",
                 "parameters": [
                     {
-                        "description": "重設資料",
-                        "name": "request",
+                        "description": "新的區塊順序",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "new_password": {
-                                    "type": "string"
-                                },
-                                "token": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/models.ReorderSectionsInput"
                         }
                     }
                 ],
@@ -758,64 +938,3306 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.CreateSectionInput": {
-            "type": "object",
-            "required": [
-                "title"
-            ],
-            "properties": {
+        },
+        "/plans/sections/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "一次回傳使用者每個未封存區塊的任務總數、已完成數與完成百分比（依排序排列，沒有任務的區塊計為 0）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得所有區塊的完成統計",
+                "operationId": "getSectionsStats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SectionStats"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據 ID 取得區塊，僅限本人的區塊，回傳格式與列表中的元素相同",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得單一區塊（Section）",
+                "operationId": "getSection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Section"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據 ID 修改區塊的標題，僅限本人操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "更新區塊（Section 標題）",
+                "operationId": "updateSection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "更新資料",
+                        "name": "section",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateSectionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據 ID 刪除一個區塊，並重新排序該使用者其他未封存的區塊（已封存的區塊保留原本的 sort_order）",
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "刪除區塊（Section）",
+                "operationId": "deleteSection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/after/{targetId}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "把區塊排到目標區塊的正後方並重新排序，兩個區塊都必須屬於本人",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "將區塊移到另一個區塊之後",
+                "operationId": "moveSectionAfter",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "要移動的 Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "目標 Section ID",
+                        "name": "targetId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/archive": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將區塊從列表中隱藏但不刪除，區塊與任務都會保留，僅限本人操作",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "封存區塊（Section）",
+                "operationId": "archiveSection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/before/{targetId}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "把區塊排到目標區塊的正前方並重新排序，兩個區塊都必須屬於本人",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "將區塊移到另一個區塊之前",
+                "operationId": "moveSectionBefore",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "要移動的 Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "目標 Section ID",
+                        "name": "targetId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/closed": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "關閉後的區塊不能再新增或移入任務，既有任務仍可編輯，僅限本人操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "關閉／重新開啟區塊（Section）",
+                "operationId": "setSectionClosed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "關閉狀態",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetSectionClosedInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/complete-all": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以單一 UPDATE 將區塊內所有未刪除、尚未完成的任務標記為完成並記錄 completed_at，回傳實際變更的任務數，僅限本人操作",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "將區塊內所有任務標記為完成",
+                "operationId": "completeAllTasks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/duplicate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以既有區塊為範本建立新區塊，標題加上「(copy)」並排在使用者所有區塊最後，所有未刪除的任務會一併複製（標題、內容、優先度），完成狀態重設為未完成。整個複製在同一個 transaction 中完成",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "複製區塊（Section）",
+                "operationId": "duplicateSection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/forecast": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "依照使用者近期的任務完成速度（每日完成數）與區塊內剩餘未完成任務數，預估區塊的完成日期",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "預估區塊完成日期",
+                "operationId": "getSectionForecast",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "計算速度的回溯天數（預設 14，最多 90）",
+                        "name": "window_days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SectionForecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/priority-breakdown": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以單一 GROUP BY 查詢統計區塊內未刪除任務在各優先度（low / medium / high）的數量，以及已完成與未完成的數量；沒有任務的優先度回傳 0",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得區塊任務的優先度分布",
+                "operationId": "getSectionPriorityBreakdown",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SectionPriorityBreakdown"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/restore-snapshot/{snapshotId}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "刪除區塊目前所有任務，並依快照內容與排序重建任務與標籤（任務會取得新的 ID），整個過程在同一個 transaction 中完成。\n垃圾桶中使用相同 external_ref 的任務會清除其 external_ref；其他未刪除的任務已使用時回傳 409",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "將區塊還原到快照",
+                "operationId": "restoreSectionSnapshot",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Snapshot ID",
+                        "name": "snapshotId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/snapshots": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "列出區塊在保留期限內的快照，由新到舊排序",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得區塊快照列表",
+                "operationId": "listSectionSnapshots",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SectionSnapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "保存區塊目前所有任務（含排序、完成狀態與標籤），之後可用來還原。每個區塊最多保留 20 份，超過保留期限（30 天）的快照會一併清除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "建立區塊快照",
+                "operationId": "createSectionSnapshot",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SectionSnapshot"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/unarchive": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "讓已封存的區塊重新出現在列表中，保留封存前的 sort_order，僅限本人操作",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取消封存區塊（Section）",
+                "operationId": "unarchiveSection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/sections/{id}/uncomplete-all": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以單一 UPDATE 將區塊內所有未刪除、已完成的任務改回未完成並清空 completed_at，回傳實際變更的任務數，僅限本人操作",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "將區塊內所有任務標記為未完成",
+                "operationId": "uncompleteAllTasks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Section ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "回傳使用者每個未封存區塊的任務總數與已完成數（依排序排列，沒有任務的區塊計為 0），並附上所有區塊合計的統計",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得計畫的完成統計",
+                "operationId": "getPlanStats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlanStats"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "回傳指定區塊（section_ids，逗號分隔）內的任務，支援分頁；group=section 時依區塊分組。任一區塊不屬於本人即回傳 403。\n帶 label 時只回傳有該標籤的任務；只帶 label 不帶 section_ids 時查詢本人所有區塊",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "依多個區塊取得任務",
+                "operationId": "listTasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "區塊 ID，逗號分隔（最多 100 個，未帶 label 時必填）",
+                        "name": "section_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只回傳帶有此標籤的任務",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "分組方式：flat（預設）或 section",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只回傳的任務欄位，逗號分隔（例如 id,title,is_completed）",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "頁碼（從 1 開始）",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每頁筆數（預設 50，最多 200）",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含仍在延後中的任務（預設不包含）",
+                        "name": "include_deferred",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只帶 label 時包含封存區塊中的任務（預設不包含）",
+                        "name": "include_archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "建立新的任務，並自動排序",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "建立任務（Task）",
+                "operationId": "createTask",
+                "parameters": [
+                    {
+                        "description": "任務內容",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateTaskInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "新任務的 URL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "與 GET /plans/tasks 相同的條件，只以 COUNT(*) 計算並透過 X-Total-Count header 回傳，不含內容",
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得任務數量（HEAD）",
+                "operationId": "countTasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "區塊 ID，逗號分隔（最多 100 個，未帶 label 時必填）",
+                        "name": "section_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只計算帶有此標籤的任務",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含仍在延後中的任務（預設不包含）",
+                        "name": "include_deferred",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只帶 label 時包含封存區塊中的任務（預設不包含）",
+                        "name": "include_archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "X-Total-Count header",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "任務總數"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/plans/tasks/batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "一次建立多個任務，所有 section_id 都必須屬於本人且未關閉；每個區塊的 sort_order 接在現有任務之後依序遞增，全部在同一個 transaction 中完成（單次最多 200 筆）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "批次建立任務（Task）",
+                "operationId": "createTasksBatch",
+                "parameters": [
+                    {
+                        "description": "任務內容",
+                        "name": "tasks",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CreateTaskInput"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/by-ref/{ref}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "依建立或更新時設定的 external_ref 找出本人的任務，方便外部系統同步",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "以外部參照取得任務",
+                "operationId": "getTaskByExternalRef",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "外部參照（external_ref）",
+                        "name": "ref",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/export.csv": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以 CSV 檔下載本人所有未刪除的任務（依區塊與任務排序），邊查詢邊寫出，資料量大時也不會整批載入記憶體；內容中的換行與逗號依 CSV 規則加上引號",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "以 CSV 匯出任務",
+                "operationId": "exportTasksCSV",
+                "responses": {
+                    "200": {
+                        "description": "CSV 內容",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/today": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "回傳本人今天（依 tz 時區的日曆日）到期、尚未完成的任務，附上所屬區塊標題；依到期時間排序，同時間再依優先度由高到低。\n篩選規則與 GET /plans/tasks/upcoming 相同：預設排除封存區塊中的任務與延後中的任務",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得今天到期的任務",
+                "operationId": "listTodayTasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "IANA 時區，例如 Asia/Taipei（預設 UTC）",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只包含這些區塊 ID，逗號分隔（最多 100 個）",
+                        "name": "section_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只回傳帶有此標籤的任務",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含仍在延後中的任務（預設不包含）",
+                        "name": "include_deferred",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "未帶 section_ids 時包含封存區塊中的任務（預設不包含）",
+                        "name": "include_archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TaskSearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "回傳本人已刪除、仍在 30 天保留期限內可還原的任務，依刪除時間由新到舊排序，支援分頁",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得垃圾桶中的任務",
+                "operationId": "listTrashedTasks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "頁碼（從 1 開始）",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每頁筆數（預設 50，最多 200）",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskPage"
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "任務總數"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "與 GET /plans/tasks/trash 相同的條件，只以 COUNT(*) 計算並透過 X-Total-Count header 回傳，不含內容",
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得垃圾桶任務數量（HEAD）",
+                "operationId": "countTrashedTasks",
+                "responses": {
+                    "200": {
+                        "description": "X-Total-Count header",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "任務總數"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/plans/tasks/upcoming": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "回傳本人在 within 時間內到期、尚未完成的任務，依到期日由近到遠排序；沒有到期日的任務不會出現。\n篩選規則與 GET /plans/tasks/today 相同：預設排除封存區塊中的任務與延後中的任務",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得即將到期的任務",
+                "operationId": "listUpcomingTasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "時間範圍，例如 7d、36h（預設 7d，最多 365d）",
+                        "name": "within",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只包含這些區塊 ID，逗號分隔（最多 100 個）",
+                        "name": "section_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只回傳帶有此標籤的任務",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含仍在延後中的任務（預設不包含）",
+                        "name": "include_deferred",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "未帶 section_ids 時包含封存區塊中的任務（預設不包含）",
+                        "name": "include_archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據 ID 取得任務，僅限本人的任務",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "取得單一任務（Task）",
+                "operationId": "getTask",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "包含已刪除（可還原）的任務",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據 ID 更新任務內容，只會更新請求中有提供的欄位",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "更新任務（Task）",
+                "operationId": "updateTask",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "更新資料",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateTaskInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據 ID 將任務移到垃圾桶（軟刪除），並重新排序同區塊內的任務；30 天內可用 restore 還原",
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "刪除任務（Task）",
+                "operationId": "deleteTask",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/{id}/complete": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "只更新任務的完成狀態（與完成時間），不會動到標題與內容",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "切換任務完成狀態",
+                "operationId": "setTaskCompleted",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "完成狀態",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetTaskCompletedInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/{id}/defer": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "設定任務的 deferred_until，時間未到前任務不會出現在預設列表中（需帶 include_deferred=true）；帶 null 取消延後",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "延後任務",
+                "operationId": "deferTask",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "延後到的時間（RFC 3339）",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeferTaskInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/{id}/labels": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "為本人的任務加上自訂標籤，同一個標籤重複加上不會產生第二筆；回傳任務目前所有標籤。\n開啟 NORMALIZE_LABELS 時標籤會轉為小寫，\"Work\" 與 \"work\" 視為同一個標籤",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "為任務加上標籤",
+                "operationId": "attachTaskLabel",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "標籤",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskLabelInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskLabels"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/{id}/labels/{label}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "移除本人任務上的指定標籤，回傳任務剩下的標籤；開啟 NORMALIZE_LABELS 時不區分大小寫",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "移除任務的標籤",
+                "operationId": "detachTaskLabel",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "標籤",
+                        "name": "label",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskLabels"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/{id}/move": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將任務移到指定區塊的指定位置，並重新排序來源與目標區塊內的任務；任務與目標區塊都必須屬於本人，目標區塊不可為關閉狀態",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "移動任務到其他區塊",
+                "operationId": "moveTask",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "目標區塊與位置",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MoveTaskInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/tasks/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將垃圾桶中的任務還原，並放到所屬區塊的最後；區塊已關閉時回傳 409",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "還原已刪除的任務",
+                "operationId": "restoreTask",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "任務 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/profile": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "使用 JWT 取得當前登入者資訊，資料由 LoadUserMiddleware 從 DB 載入並短暫快取；token 簽發後帳號已刪除時回傳 404",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "取得個人資訊",
+                "operationId": "getProfile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserProfile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "修改目前登入者的 username 與 email。更換 email 後帳號會回到未驗證狀態並寄出新的驗證信，驗證後才能再次登入",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "更新個人資訊",
+                "operationId": "updateProfile",
+                "parameters": [
+                    {
+                        "description": "個人資訊",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "確認目前密碼後，刪除目前登入者的帳號與所有區塊、任務等資料，無法復原",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "刪除帳號",
+                "operationId": "deleteAccount",
+                "parameters": [
+                    {
+                        "description": "目前密碼",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteUserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/footprint": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "回傳使用者的區塊、任務、區塊快照與任務標籤數量，以及以文字欄位長度估算的資料大小（僅供參考）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "取得個人資料使用量",
+                "operationId": "getFootprint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataFootprint"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "使用 refresh token 取得新的 access token，並輪替 refresh token（舊的 token 立即失效，重複使用會失敗）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "更新 Access Token",
+                "operationId": "refresh",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "refresh_token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "使用者註冊帳號，並寄出 email 驗證信",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "註冊使用者",
+                "operationId": "register",
+                "parameters": [
+                    {
+                        "description": "使用者資料",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRegisterInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/resend-verification": {
+            "post": {
+                "description": "為尚未驗證的帳號寄出新的 email 驗證信。不論 email 是否已註冊或已驗證都回傳相同的 200 訊息，只有未驗證的帳號才會寄信；\n同一帳號在 EMAIL_VERIFICATION_THROTTLE（預設 2 分鐘）內重複請求時不會再寄信",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "重寄驗證信",
+                "operationId": "resendVerification",
+                "parameters": [
+                    {
+                        "description": "Email 地址",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "email": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/reset-password": {
+            "post": {
+                "description": "使用 token 重設用戶密碼",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "重設密碼",
+                "operationId": "resetPassword",
+                "parameters": [
+                    {
+                        "description": "重設資料",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "new_password": {
+                                    "type": "string"
+                                },
+                                "token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/verify-email": {
+            "get": {
+                "description": "使用註冊時寄出的 token 驗證 email，驗證後才能登入",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "驗證 Email",
+                "operationId": "verifyEmail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "驗證 token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "SECTION_NOT_FOUND"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Section not found"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f1c2a9e-5b7d-4e2a-9c1f-0a8b6d4e2f10"
+                }
+            }
+        },
+        "models.BatchError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "SECTION_NOT_FOUND"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Section not found"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f1c2a9e-5b7d-4e2a-9c1f-0a8b6d4e2f10"
+                }
+            }
+        },
+        "models.BatchInput": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "maxItems": 200,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.BatchOperation"
+                    }
+                }
+            }
+        },
+        "models.BatchOperation": {
+            "type": "object",
+            "required": [
+                "op",
+                "type"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_completed": {
+                    "type": "boolean"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "move"
+                    ],
+                    "example": "create"
+                },
+                "ref": {
+                    "type": "string"
+                },
+                "section_id": {
+                    "type": "integer"
+                },
+                "section_ref": {
+                    "type": "string"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "temp_id": {
+                    "type": "string",
+                    "example": "tmp-1"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "section",
+                        "task"
+                    ],
+                    "example": "task"
+                }
+            }
+        },
+        "models.BatchOperationResult": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "temp_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BatchResult": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchOperationResult"
+                    }
+                }
+            }
+        },
+        "models.CompletionCounts": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer"
+                },
+                "incomplete": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CreateSectionInput": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "client_id": {
+                    "description": "前端樂觀更新用的暫時 ID，會原封不動回傳",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateTaskInput": {
+            "type": "object",
+            "required": [
+                "content",
+                "section_id",
+                "title"
+            ],
+            "properties": {
+                "client_id": {
+                    "description": "前端樂觀更新用的暫時 ID，會原封不動回傳",
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "deferred_until": {
+                    "type": "string"
+                },
+                "due_date": {
+                    "description": "RFC 3339 時間（含時區），例如 2026-01-02T09:00:00+08:00",
+                    "type": "string"
+                },
+                "external_ref": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "is_completed": {
+                    "type": "boolean"
+                },
+                "priority": {
+                    "description": "未提供時為 medium",
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ]
+                },
+                "section_id": {
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
                 }
             }
         },
-        "models.CreateTaskInput": {
+        "models.DataFootprint": {
+            "type": "object",
+            "properties": {
+                "section_snapshots": {
+                    "description": "區塊快照以 JSON 保存的任務內容",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResourceFootprint"
+                        }
+                    ]
+                },
+                "sections": {
+                    "$ref": "#/definitions/models.ResourceFootprint"
+                },
+                "task_labels": {
+                    "$ref": "#/definitions/models.ResourceFootprint"
+                },
+                "tasks": {
+                    "$ref": "#/definitions/models.ResourceFootprint"
+                },
+                "total_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DeferTaskInput": {
+            "type": "object",
+            "properties": {
+                "deferred_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeleteUserInput": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "12345678"
+                }
+            }
+        },
+        "models.MoveTaskInput": {
+            "type": "object",
+            "required": [
+                "section_id"
+            ],
+            "properties": {
+                "section_id": {
+                    "type": "integer"
+                },
+                "sort_order": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PlanExport": {
+            "type": "object",
+            "properties": {
+                "exported_at": {
+                    "type": "string"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SectionWithTasks"
+                    }
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.PlanImportResult": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "example": "append"
+                },
+                "sections": {
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PlanStats": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer"
+                },
+                "percentage": {
+                    "type": "number"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SectionStats"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PriorityCounts": {
+            "type": "object",
+            "properties": {
+                "high": {
+                    "type": "integer"
+                },
+                "low": {
+                    "type": "integer"
+                },
+                "medium": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RecentSection": {
             "type": "object",
-            "required": [
-                "content",
-                "section_id",
-                "title"
-            ],
             "properties": {
-                "content": {
+                "created_at": {
                     "type": "string"
                 },
-                "is_completed": {
+                "id": {
+                    "type": "integer"
+                },
+                "is_archived": {
                     "type": "boolean"
                 },
-                "section_id": {
+                "is_closed": {
+                    "type": "boolean"
+                },
+                "last_active_at": {
+                    "type": "string"
+                },
+                "sort_order": {
                     "type": "integer"
                 },
                 "title": {
                     "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReorderSectionsInput": {
+            "type": "object",
+            "required": [
+                "section_ids"
+            ],
+            "properties": {
+                "section_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        1,
+                        2
+                    ]
+                }
+            }
+        },
+        "models.ResourceFootprint": {
+            "type": "object",
+            "properties": {
+                "approx_bytes": {
+                    "type": "integer"
+                },
+                "count": {
+                    "type": "integer"
                 }
             }
         },
@@ -828,6 +4250,12 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "is_archived": {
+                    "type": "boolean"
+                },
+                "is_closed": {
+                    "type": "boolean"
+                },
                 "sort_order": {
                     "type": "integer"
                 },
@@ -836,6 +4264,89 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SectionForecast": {
+            "type": "object",
+            "properties": {
+                "completed_in_window": {
+                    "type": "integer"
+                },
+                "estimated_completion_date": {
+                    "type": "string"
+                },
+                "estimated_days": {
+                    "type": "integer"
+                },
+                "remaining_tasks": {
+                    "type": "integer"
+                },
+                "section_id": {
+                    "type": "integer"
+                },
+                "velocity_per_day": {
+                    "type": "number"
+                },
+                "window_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SectionPriorityBreakdown": {
+            "type": "object",
+            "properties": {
+                "by_completion": {
+                    "$ref": "#/definitions/models.CompletionCounts"
+                },
+                "by_priority": {
+                    "$ref": "#/definitions/models.PriorityCounts"
+                },
+                "section_id": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SectionSnapshot": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "section_id": {
+                    "type": "integer"
+                },
+                "task_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SectionStats": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer"
+                },
+                "percentage": {
+                    "type": "number"
+                },
+                "section_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
@@ -848,6 +4359,12 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "is_archived": {
+                    "type": "boolean"
+                },
+                "is_closed": {
+                    "type": "boolean"
+                },
                 "sort_order": {
                     "type": "integer"
                 },
@@ -862,6 +4379,31 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SetSectionClosedInput": {
+            "type": "object",
+            "required": [
+                "is_closed"
+            ],
+            "properties": {
+                "is_closed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SetTaskCompletedInput": {
+            "type": "object",
+            "required": [
+                "is_completed"
+            ],
+            "properties": {
+                "is_completed": {
+                    "type": "boolean"
                 }
             }
         },
@@ -874,15 +4416,143 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "deferred_until": {
+                    "description": "延後到此時間（UTC）之前，任務不會出現在預設列表中",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "軟刪除時間，只有 include_deleted 時才會出現",
+                    "type": "string"
+                },
+                "due_date": {
+                    "description": "到期時間（UTC），沒有到期日時為 null",
+                    "type": "string"
+                },
+                "external_ref": {
+                    "description": "外部系統（例如 GitHub issue）的對應 ID，同一使用者內不可重複",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_completed": {
+                    "type": "boolean"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ],
+                    "example": "medium"
+                },
+                "section_id": {
+                    "type": "integer"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "樂觀鎖版本，排序或移動時遞增；批次排序時需帶回最後讀到的值",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TaskLabelInput": {
+            "type": "object",
+            "required": [
+                "label"
+            ],
+            "properties": {
+                "label": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "work"
+                }
+            }
+        },
+        "models.TaskLabels": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TaskPage": {
+            "type": "object",
+            "properties": {
+                "items": {},
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TaskSearchResult": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deferred_until": {
+                    "description": "延後到此時間（UTC）之前，任務不會出現在預設列表中",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "軟刪除時間，只有 include_deleted 時才會出現",
+                    "type": "string"
+                },
+                "due_date": {
+                    "description": "到期時間（UTC），沒有到期日時為 null",
+                    "type": "string"
+                },
+                "external_ref": {
+                    "description": "外部系統（例如 GitHub issue）的對應 ID，同一使用者內不可重複",
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "is_completed": {
                     "type": "boolean"
                 },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ],
+                    "example": "medium"
+                },
                 "section_id": {
                     "type": "integer"
                 },
+                "section_title": {
+                    "type": "string"
+                },
                 "sort_order": {
                     "type": "integer"
                 },
@@ -891,6 +4561,10 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "樂觀鎖版本，排序或移動時遞增；批次排序時需帶回最後讀到的值",
+                    "type": "integer"
                 }
             }
         },
@@ -911,14 +4585,55 @@ const docTemplate = `{
                 "content": {
                     "type": "string"
                 },
+                "deferred_until": {
+                    "description": "清除延後請改用 PUT /plans/tasks/{id}/defer 並帶 null",
+                    "type": "string"
+                },
+                "due_date": {
+                    "description": "帶 null 代表清除到期日",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "external_ref": {
+                    "description": "空字串代表清除 external_ref",
+                    "type": "string",
+                    "maxLength": 255
+                },
                 "is_completed": {
                     "type": "boolean"
                 },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ]
+                },
                 "title": {
                     "type": "string"
                 }
             }
         },
+        "models.UpdateUserInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "w@w.com"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "walter"
+                }
+            }
+        },
         "models.UserLoginInput": {
             "type": "object",
             "properties": {
@@ -928,12 +4643,43 @@ const docTemplate = `{
                 },
                 "password": {
                     "type": "string",
-                    "example": "123456"
+                    "example": "12345678"
+                }
+            }
+        },
+        "models.UserProfile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "description": "最後一次成功登入的時間，從未登入時為 null",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "舊版回應就有的欄位，保留以維持相容",
+                    "type": "string",
+                    "example": "You are authenticated!"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
         "models.UserRegisterInput": {
             "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
             "properties": {
                 "email": {
                     "type": "string",
@@ -941,7 +4687,8 @@ const docTemplate = `{
                 },
                 "password": {
                     "type": "string",
-                    "example": "123456"
+                    "minLength": 8,
+                    "example": "12345678"
                 },
                 "username": {
                     "type": "string",
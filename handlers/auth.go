@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"strings"
+	"strconv"
 	"time"
 
+	"github.com/Walter1412/micro-backend/config"
+	"github.com/Walter1412/micro-backend/db"
+	"github.com/Walter1412/micro-backend/middlewares"
 	"github.com/Walter1412/micro-backend/models"
 	"github.com/Walter1412/micro-backend/services"
 	"github.com/gin-gonic/gin"
@@ -17,15 +20,20 @@ import (
 
 // Login godoc
 // @Summary      使用者登入
-// @Description  輸入 email 與密碼後登入並取得 JWT Token
+// @Description  輸入 email 與密碼後登入並取得 JWT Token 與 refresh token
+// @ID           login
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        login  body  models.UserLoginInput  true  "登入資訊"
 // @Success      200    {object}  map[string]string
-// @Failure      400    {object}  map[string]string
+// @Failure      400    {object}  models.APIError
+// @Failure      401    {object}  models.APIError
+// @Failure      403    {object}  models.APIError
+// @Failure      429    {object}  models.APIError
+// @Failure      500    {object}  models.APIError
 // @Router       /login [post]
-func Login(database *sql.DB) gin.HandlerFunc {
+func Login(database *sql.DB, cfg *config.Config, loginLimiter *services.LoginLimiter) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		var input struct {
 			Email    string `json:"email"`
@@ -33,70 +41,185 @@ func Login(database *sql.DB) gin.HandlerFunc {
 		}
 
 		if error := context.ShouldBindJSON(&input); error != nil {
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
+			return
+		}
+
+		// ✅ 同一帳號連續失敗太多次時暫停登入，防止暴力破解
+		if retryAfter, isBlocked := loginLimiter.RetryAfter(input.Email); isBlocked {
+			retryAfterSeconds := int(retryAfter.Seconds()) + 1
+			context.Header("Retry-After", strconv.Itoa(retryAfterSeconds))
+			context.JSON(http.StatusTooManyRequests, gin.H{
+				"code":        CodeTooManyRequests,
+				"error":       "Too many failed login attempts",
+				"retry_after": fmt.Sprintf("%ds", retryAfterSeconds),
+			})
 			return
 		}
 
 		user, error := models.GetUserByEmail(database, input.Email)
+		if errors.Is(error, sql.ErrNoRows) {
+			loginLimiter.RecordFailure(input.Email)
+			RespondError(context, http.StatusUnauthorized, CodeInvalidCredentials, "User not found")
+			return
+		}
 		if error != nil {
-			context.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			// DB 故障不是帳密錯誤，不計入登入失敗次數
+			fmt.Printf("🚨 GetUserByEmail error: %v\n", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Service unavailable")
 			return
 		}
 
 		if error := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); error != nil {
-			context.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect password"})
+			loginLimiter.RecordFailure(input.Email)
+			RespondError(context, http.StatusUnauthorized, CodeInvalidCredentials, "Incorrect password")
+			return
+		}
+		loginLimiter.Reset(input.Email)
+
+		if !user.IsVerified {
+			RespondError(context, http.StatusForbidden, CodeEmailNotVerified, "Email not verified, please check your inbox for the verification link or request a new one")
 			return
 		}
 
 		// 🔐 建立 JWT token
-		claims := jwt.MapClaims{
-			"user_id":  user.ID,
-			"username": user.Username,
-			"exp":      time.Now().Add(time.Hour * 72).Unix(),
+		tokenString, error := issueAccessToken(user, cfg.Server)
+		if error != nil {
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Token signing failed")
+			return
+		}
+
+		refreshToken, error := models.CreateRefreshToken(database, user.ID, refreshTokenTTL)
+		if error != nil {
+			fmt.Printf("🚨 CreateRefreshToken error: %v\n", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to create refresh token")
+			return
+		}
+
+		// ✅ 登入成功才記錄最後登入時間，放在背景執行不影響回應時間
+		go func(userID int) {
+			if error := models.TouchLastLogin(database, userID); error != nil {
+				fmt.Printf("🚨 TouchLastLogin error: %v\n", error)
+			}
+		}(user.ID)
+
+		context.JSON(http.StatusOK, gin.H{
+			"token":         tokenString,
+			"refresh_token": refreshToken.Token,
+		})
+	}
+}
+
+// Refresh godoc
+// @Summary      更新 Access Token
+// @Description  使用 refresh token 取得新的 access token，並輪替 refresh token（舊的 token 立即失效，重複使用會失敗）
+// @ID           refresh
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  object{refresh_token=string}  true  "Refresh token"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  models.APIError
+// @Failure      401    {object}  models.APIError
+// @Failure      500    {object}  models.APIError
+// @Router       /refresh [post]
+func Refresh(database *sql.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var input struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
 		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "default_secret"
+		if error := context.ShouldBindJSON(&input); error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
+			return
 		}
 
-		tokenString, error := token.SignedString([]byte(secret))
+		// ✅ 將舊 token 標記為已使用與建立新 token 放在同一個 transaction，
+		// 並行的重複請求只有一個會成功，後續步驟失敗時舊 token 也不會被用掉
+		var tokenString string
+		var refreshToken *models.RefreshToken
+		error := db.WithTransaction(database, func(transaction *sql.Tx) error {
+			userID, error := models.ConsumeRefreshToken(transaction, input.RefreshToken)
+			if error != nil {
+				return error
+			}
+
+			user, error := models.GetUserByID(database, userID)
+			if error != nil {
+				return error
+			}
+
+			tokenString, error = issueAccessToken(user, cfg.Server)
+			if error != nil {
+				return error
+			}
+
+			refreshToken, error = models.CreateRefreshToken(transaction, user.ID, refreshTokenTTL)
+			return error
+		})
+		if errors.Is(error, models.ErrRefreshTokenInvalid) {
+			RespondError(context, http.StatusUnauthorized, CodeInvalidToken, "Invalid or expired refresh token")
+			return
+		}
+		if errors.Is(error, sql.ErrNoRows) {
+			RespondError(context, http.StatusUnauthorized, CodeInvalidCredentials, "User not found")
+			return
+		}
 		if error != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Token signing failed"})
+			// DB 故障不代表 token 無效，回傳 5xx 讓用戶端稍後重試而不是登出
+			fmt.Printf("🚨 Refresh error: %v\n", error)
+			respondServerError(context, "Failed to refresh token")
 			return
 		}
 
-		context.JSON(http.StatusOK, gin.H{"token": tokenString})
+		context.JSON(http.StatusOK, gin.H{
+			"token":         tokenString,
+			"refresh_token": refreshToken.Token,
+		})
+	}
+}
+
+const refreshTokenTTL = time.Hour * 24 * 30
+
+func issueAccessToken(user *models.User, serverConfig config.ServerConfig) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"iat":      now.Unix(),
+		"exp":      now.Add(serverConfig.AccessTokenTTL).Unix(),
 	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(serverConfig.JWTSecret))
 }
 
 // Register godoc
 // @Summary      註冊使用者
-// @Description  使用者註冊帳號
+// @Description  使用者註冊帳號，並寄出 email 驗證信
+// @ID           register
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        user  body  models.UserRegisterInput  true  "使用者資料"
 // @Success      200  {object}  map[string]string
-// @Failure      400  {object}  map[string]string
+// @Failure      400  {object}  models.APIError
+// @Failure      409  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
 // @Router       /register [post]
-func Register(database *sql.DB) gin.HandlerFunc {
+func Register(database *sql.DB, emailService services.EmailSender, cfg *config.Config) gin.HandlerFunc {
 	return func(context *gin.Context) {
-		var input struct {
-			Username string `json:"username"`
-			Email    string `json:"email"`
-			Password string `json:"password"`
-		}
+		var input models.UserRegisterInput
 
+		// ✅ email 格式與密碼長度由 binding 標籤驗證
 		if error := context.ShouldBindJSON(&input); error != nil {
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, validationErrorMessage(error))
 			return
 		}
 
-		hashed, error := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		hashed, error := services.HashPassword(input.Password, cfg.Server.BcryptCost)
 		if error != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Password hash failed"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Password hash failed")
 			return
 		}
 
@@ -106,60 +229,193 @@ func Register(database *sql.DB) gin.HandlerFunc {
 			PasswordHash: string(hashed),
 		}
 
+		// users.email / users.username 有唯一索引，重複時 CreateUser 會回傳對應的錯誤
 		if error := models.CreateUser(database, &user); error != nil {
-			// 解析 MySQL 重複鍵錯誤
-			if strings.Contains(error.Error(), "Duplicate entry") {
-				if strings.Contains(error.Error(), "username") {
-					context.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
-				} else if strings.Contains(error.Error(), "email") {
-					context.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-				} else {
-					context.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
-				}
+			if errors.Is(error, models.ErrDuplicateEmail) {
+				RespondError(context, http.StatusConflict, CodeEmailTaken, "Email already registered")
+				return
+			}
+			if errors.Is(error, models.ErrDuplicateUsername) {
+				RespondError(context, http.StatusConflict, CodeUsernameTaken, "Username already exists")
 				return
 			}
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "User creation failed"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "User creation failed")
 			return
 		}
+		fmt.Printf("✅ User created: ID=%d, Email=%s\n", user.ID, user.Email)
 
-		context.JSON(http.StatusOK, gin.H{"message": "User registered"})
+		// ✅ 帳號已建立，驗證信失敗只記錄錯誤，使用者之後可透過 POST /resend-verification 重新申請
+		verification, error := models.CreateEmailVerification(database, user.ID)
+		if error != nil {
+			fmt.Printf("🚨 CreateEmailVerification error: %v\n", error)
+		} else if error := emailService.SendVerificationEmail(user.Email, verification.Token); error != nil {
+			fmt.Printf("🚨 SendVerificationEmail error: %v\n", error)
+		}
+
+		// 歡迎信在背景寄送，不影響註冊回應時間，失敗只記錄錯誤
+		go func(email, username string) {
+			if error := emailService.SendWelcomeEmail(email, username); error != nil {
+				fmt.Printf("🚨 SendWelcomeEmail error: %v\n", error)
+			}
+		}(user.Email, user.Username)
+
+		context.JSON(http.StatusOK, gin.H{"message": "User registered, please verify your email"})
+	}
+}
+
+// VerifyEmail godoc
+// @Summary      驗證 Email
+// @Description  使用註冊時寄出的 token 驗證 email，驗證後才能登入
+// @ID           verifyEmail
+// @Tags         Auth
+// @Produce      json
+// @Param        token  query  string  true  "驗證 token"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  models.APIError
+// @Failure      500    {object}  models.APIError
+// @Router       /verify-email [get]
+func VerifyEmail(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		token := context.Query("token")
+		if token == "" {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "token is required")
+			return
+		}
+
+		userID, error := models.VerifyEmail(database, token)
+		if errors.Is(error, models.ErrVerificationTokenInvalid) {
+			RespondError(context, http.StatusBadRequest, CodeInvalidToken, "Invalid or expired verification token")
+			return
+		}
+		if error != nil {
+			fmt.Printf("🚨 VerifyEmail error: %v\n", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to verify email")
+			return
+		}
+		fmt.Printf("✅ Email verified: UserID=%d\n", userID)
+
+		context.JSON(http.StatusOK, gin.H{"message": "Email verified"})
 	}
 }
 
+// resendVerificationMessage 為重寄驗證信固定的回應訊息，不論 email 是否存在或已驗證都相同，避免被用來探測帳號
+const resendVerificationMessage = "If that email exists and is not verified, a verification link was sent"
+
+// ResendVerification godoc
+// @Summary      重寄驗證信
+// @Description  為尚未驗證的帳號寄出新的 email 驗證信。不論 email 是否已註冊或已驗證都回傳相同的 200 訊息，只有未驗證的帳號才會寄信；
+// @Description  同一帳號在 EMAIL_VERIFICATION_THROTTLE（預設 2 分鐘）內重複請求時不會再寄信
+// @ID           resendVerification
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  object{email=string}  true  "Email 地址"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  models.APIError
+// @Failure      500    {object}  models.APIError
+// @Router       /resend-verification [post]
+func ResendVerification(database *sql.DB, emailService services.EmailSender, cfg *config.Config) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var input struct {
+			Email string `json:"email"`
+		}
+
+		if error := context.ShouldBindJSON(&input); error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
+			return
+		}
+
+		user, error := models.GetUserByEmail(database, input.Email)
+		if errors.Is(error, sql.ErrNoRows) {
+			fmt.Printf("🚨 Verification resend requested for unknown email: %s\n", input.Email)
+			context.JSON(http.StatusOK, gin.H{"message": resendVerificationMessage})
+			return
+		}
+		if error != nil {
+			fmt.Printf("🚨 GetUserByEmail error: %v\n", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to process request")
+			return
+		}
+		if user.IsVerified {
+			fmt.Printf("🚨 Verification resend requested for verified user: ID=%d\n", user.ID)
+			context.JSON(http.StatusOK, gin.H{"message": resendVerificationMessage})
+			return
+		}
+
+		// 帳號存在之後的失敗只記錄在 log，回應與帳號不存在時相同
+		verification, error := models.CreateEmailVerificationContext(context.Request.Context(), database, user.ID, cfg.Server.EmailVerificationThrottle)
+		if errors.Is(error, models.ErrEmailVerificationThrottled) {
+			fmt.Printf("🚨 Verification resend throttled: UserID=%d\n", user.ID)
+			context.JSON(http.StatusOK, gin.H{"message": resendVerificationMessage})
+			return
+		}
+		if error != nil {
+			fmt.Printf("🚨 CreateEmailVerification error: %v\n", error)
+			context.JSON(http.StatusOK, gin.H{"message": resendVerificationMessage})
+			return
+		}
+
+		if error := emailService.SendVerificationEmail(user.Email, verification.Token); error != nil {
+			fmt.Printf("🚨 SendVerificationEmail error: %v\n", error)
+			context.JSON(http.StatusOK, gin.H{"message": resendVerificationMessage})
+			return
+		}
+		fmt.Printf("✅ Verification email resent: UserID=%d\n", user.ID)
+
+		context.JSON(http.StatusOK, gin.H{"message": resendVerificationMessage})
+	}
+}
+
+// forgotPasswordMessage 為忘記密碼固定的回應訊息，不論 email 是否存在都相同，避免被用來探測帳號
+const forgotPasswordMessage = "If that email exists, a reset link was sent"
+
 // ForgotPassword godoc
 // @Summary      忘記密碼
-// @Description  發送重設密碼信件到用戶 email
+// @Description  發送重設密碼信件到用戶 email。不論 email 是否已註冊都回傳相同的 200 訊息，只有帳號存在時才會寄信；
+// @Description  同一帳號在 PASSWORD_RESET_THROTTLE（預設 2 分鐘）內重複請求時不會再寄信
+// @ID           forgotPassword
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        request  body  object{email=string}  true  "Email 地址"
 // @Success      200    {object}  map[string]string
-// @Failure      400    {object}  map[string]string
-// @Failure      404    {object}  map[string]string
+// @Failure      400    {object}  models.APIError
+// @Failure      500    {object}  models.APIError
 // @Router       /forgot-password [post]
-func ForgotPassword(database *sql.DB, emailService *services.EmailService) gin.HandlerFunc {
+func ForgotPassword(database *sql.DB, emailService services.EmailSender, cfg *config.Config) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		var input struct {
 			Email string `json:"email"`
 		}
 
 		if error := context.ShouldBindJSON(&input); error != nil {
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
 			return
 		}
 
 		user, error := models.GetUserByEmail(database, input.Email)
+		if errors.Is(error, sql.ErrNoRows) {
+			fmt.Printf("🚨 Password reset requested for unknown email: %s\n", input.Email)
+			context.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+			return
+		}
 		if error != nil {
 			fmt.Printf("🚨 GetUserByEmail error: %v\n", error)
-			context.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to process request")
 			return
 		}
 		fmt.Printf("✅ User found: ID=%d, Email=%s\n", user.ID, user.Email)
 
-		passwordReset, error := models.CreatePasswordReset(database, user.ID)
+		// 帳號存在之後的失敗只記錄在 log，回應與帳號不存在時相同
+		passwordReset, error := models.CreatePasswordResetContext(context.Request.Context(), database, user.ID, cfg.Server.PasswordResetThrottle)
+		if errors.Is(error, models.ErrPasswordResetThrottled) {
+			fmt.Printf("🚨 Password reset throttled: UserID=%d\n", user.ID)
+			context.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+			return
+		}
 		if error != nil {
 			fmt.Printf("🚨 CreatePasswordReset error: %v\n", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+			context.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
 			return
 		}
 		fmt.Printf("✅ Token created: %s\n", passwordReset.Token)
@@ -167,27 +423,28 @@ func ForgotPassword(database *sql.DB, emailService *services.EmailService) gin.H
 		error = emailService.SendPasswordResetEmail(user.Email, passwordReset.Token)
 		if error != nil {
 			fmt.Printf("🚨 SendPasswordResetEmail error: %v\n", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send email"})
+			context.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
 			return
 		}
 		fmt.Printf("✅ Email process completed\n")
 
-		context.JSON(http.StatusOK, gin.H{"message": "Password reset email sent"})
+		context.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
 	}
 }
 
 // ResetPassword godoc
 // @Summary      重設密碼
 // @Description  使用 token 重設用戶密碼
+// @ID           resetPassword
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        request  body  object{token=string,new_password=string}  true  "重設資料"
 // @Success      200    {object}  map[string]string
-// @Failure      400    {object}  map[string]string
-// @Failure      404    {object}  map[string]string
+// @Failure      400    {object}  models.APIError
+// @Failure      404    {object}  models.APIError
 // @Router       /reset-password [post]
-func ResetPassword(database *sql.DB) gin.HandlerFunc {
+func ResetPassword(database *sql.DB, cfg *config.Config, userCache *middlewares.UserCache) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		var input struct {
 			Token       string `json:"token"`
@@ -195,31 +452,33 @@ func ResetPassword(database *sql.DB) gin.HandlerFunc {
 		}
 
 		if error := context.ShouldBindJSON(&input); error != nil {
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
 			return
 		}
 
-		passwordReset, error := models.GetPasswordResetByToken(database, input.Token)
+		passwordReset, error := models.GetPasswordResetByTokenContext(context.Request.Context(), database, input.Token)
 		if error != nil {
-			context.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired reset token"})
+			RespondError(context, http.StatusNotFound, CodeInvalidToken, "Invalid or expired reset token")
 			return
 		}
 
-		hashed, error := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+		hashed, error := services.HashPassword(input.NewPassword, cfg.Server.BcryptCost)
 		if error != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Password hash failed"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Password hash failed")
 			return
 		}
 
 		error = models.UpdateUserPassword(database, passwordReset.UserID, string(hashed))
 		if error != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to update password")
 			return
 		}
+		// 刪除帳號時會以快取中的密碼雜湊驗證，重設後必須清掉
+		userCache.Evict(int64(passwordReset.UserID))
 
-		error = models.MarkPasswordResetAsUsed(database, input.Token)
+		error = models.MarkPasswordResetAsUsedContext(context.Request.Context(), database, input.Token)
 		if error != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark token as used"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to mark token as used")
 			return
 		}
 
@@ -229,11 +488,12 @@ func ResetPassword(database *sql.DB) gin.HandlerFunc {
 
 // GetLatestToken godoc
 // @Summary      獲取最新的重設密碼 token (僅供開發測試)
-// @Description  返回最新的未使用密碼重設 token，僅供開發環境測試使用
+// @Description  返回最新的未使用密碼重設 token，僅供開發環境測試使用；只有設定 APP_ENV=development 或 ENABLE_DEV_ENDPOINTS=true 時才會註冊
+// @ID           getLatestToken
 // @Tags         Auth
 // @Produce      json
 // @Success      200    {object}  map[string]string
-// @Failure      404    {object}  map[string]string
+// @Failure      404    {object}  models.APIError
 // @Router       /dev/latest-token [get]
 func GetLatestToken(database *sql.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
@@ -243,7 +503,7 @@ func GetLatestToken(database *sql.DB) gin.HandlerFunc {
 		var userID int
 		error := row.Scan(&token, &userID)
 		if error != nil {
-			context.JSON(http.StatusNotFound, gin.H{"error": "No unused tokens found"})
+			RespondError(context, http.StatusNotFound, CodeNotFound, "No unused tokens found")
 			return
 		}
 
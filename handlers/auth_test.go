@@ -0,0 +1,522 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Walter1412/micro-backend/config"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/Walter1412/micro-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const consumeRefreshTokenQuery = "UPDATE refresh_tokens SET used = TRUE WHERE token = \\? AND used = FALSE AND expires_at > NOW\\(\\)"
+
+func newRefreshRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.POST("/refresh", Refresh(database, testConfig()))
+	return router, mock
+}
+
+func TestRefreshRotatesTokenInOneTransaction(t *testing.T) {
+	router, mock := newRefreshRouter(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(consumeRefreshTokenQuery).WithArgs("old-token").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT user_id FROM refresh_tokens").WithArgs("old-token").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(7))
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE id = \\?").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(userColumns()).AddRow(7, "walter", "w@w.com", "hash", true, nil, time.Now()))
+	mock.ExpectExec("INSERT INTO refresh_tokens").WithArgs(7, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	recorder := performRequest(router, http.MethodPost, "/refresh", `{"refresh_token":"old-token"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var response map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response["token"] == "" || response["refresh_token"] == "" {
+		t.Fatalf("expected token and refresh_token, got %v", response)
+	}
+}
+
+func TestRefreshRejectsUsedToken(t *testing.T) {
+	router, mock := newRefreshRouter(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(consumeRefreshTokenQuery).WithArgs("used-token").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	recorder := performRequest(router, http.MethodPost, "/refresh", `{"refresh_token":"used-token"}`)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if code := decodeAPIError(t, recorder).Code; code != CodeInvalidToken {
+		t.Fatalf("expected %s, got %s", CodeInvalidToken, code)
+	}
+}
+
+func TestRefreshDatabaseErrorIsNotUnauthorized(t *testing.T) {
+	router, mock := newRefreshRouter(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(consumeRefreshTokenQuery).WithArgs("old-token").WillReturnError(errors.New("connection refused"))
+	mock.ExpectRollback()
+
+	recorder := performRequest(router, http.MethodPost, "/refresh", `{"refresh_token":"old-token"}`)
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestRefreshRollsBackWhenNewTokenInsertFails(t *testing.T) {
+	router, mock := newRefreshRouter(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(consumeRefreshTokenQuery).WithArgs("old-token").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT user_id FROM refresh_tokens").WithArgs("old-token").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(7))
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE id = \\?").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(userColumns()).AddRow(7, "walter", "w@w.com", "hash", true, nil, time.Now()))
+	mock.ExpectExec("INSERT INTO refresh_tokens").WillReturnError(errors.New("insert failed"))
+	// 舊 token 的 used = TRUE 隨 rollback 復原，使用者仍可重試
+	mock.ExpectRollback()
+
+	recorder := performRequest(router, http.MethodPost, "/refresh", `{"refresh_token":"old-token"}`)
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func newLoginRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock, *services.LoginLimiter) {
+	database, mock := newMockDB(t)
+	// 上限為 1，只要記錄一次失敗就會被暫停，方便確認哪些錯誤有被計入
+	loginLimiter := services.NewLoginLimiter(1, time.Minute)
+	router := gin.New()
+	router.POST("/login", Login(database, testConfig(), loginLimiter))
+	return router, mock, loginLimiter
+}
+
+func TestLoginUnknownEmailReturns401(t *testing.T) {
+	router, mock, loginLimiter := newLoginRouter(t)
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE email = \\?").WithArgs("nobody@example.com").WillReturnError(sql.ErrNoRows)
+
+	recorder := performRequest(router, http.MethodPost, "/login", `{"email":"nobody@example.com","password":"12345678"}`)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if code := decodeAPIError(t, recorder).Code; code != CodeInvalidCredentials {
+		t.Fatalf("expected %s, got %s", CodeInvalidCredentials, code)
+	}
+	if _, isBlocked := loginLimiter.RetryAfter("nobody@example.com"); !isBlocked {
+		t.Fatal("an unknown email should count as a failed login")
+	}
+}
+
+func TestLoginDatabaseErrorReturns500WithoutCountingFailure(t *testing.T) {
+	router, mock, loginLimiter := newLoginRouter(t)
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE email = \\?").WithArgs("w@w.com").WillReturnError(errors.New("connection refused"))
+
+	recorder := performRequest(router, http.MethodPost, "/login", `{"email":"w@w.com","password":"12345678"}`)
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if code := decodeAPIError(t, recorder).Code; code != CodeInternal {
+		t.Fatalf("expected %s, got %s", CodeInternal, code)
+	}
+	if _, isBlocked := loginLimiter.RetryAfter("w@w.com"); isBlocked {
+		t.Fatal("a DB failure must not count as a failed login")
+	}
+}
+
+// emailCall 記錄一次寄信呼叫；value 為 token 或 username
+type emailCall struct {
+	method string
+	to     string
+	value  string
+}
+
+// mockEmailSender 實作 services.EmailSender，記錄所有呼叫並回傳 err。
+// 背景 goroutine 寄出的信可以透過 sent 等待
+type mockEmailSender struct {
+	mutex sync.Mutex
+	calls []emailCall
+	err   error
+	sent  chan emailCall
+}
+
+var _ services.EmailSender = (*mockEmailSender)(nil)
+
+func newMockEmailSender() *mockEmailSender {
+	return &mockEmailSender{sent: make(chan emailCall, 10)}
+}
+
+func (sender *mockEmailSender) record(method string, to string, value string) error {
+	call := emailCall{method: method, to: to, value: value}
+	sender.mutex.Lock()
+	sender.calls = append(sender.calls, call)
+	sender.mutex.Unlock()
+	select {
+	case sender.sent <- call:
+	default:
+	}
+	return sender.err
+}
+
+func (sender *mockEmailSender) SendPasswordResetEmail(toEmail, token string) error {
+	return sender.record("SendPasswordResetEmail", toEmail, token)
+}
+
+func (sender *mockEmailSender) SendVerificationEmail(toEmail, token string) error {
+	return sender.record("SendVerificationEmail", toEmail, token)
+}
+
+func (sender *mockEmailSender) SendWelcomeEmail(toEmail, username string) error {
+	return sender.record("SendWelcomeEmail", toEmail, username)
+}
+
+func (sender *mockEmailSender) Calls() []emailCall {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	return append([]emailCall(nil), sender.calls...)
+}
+
+// waitForCall 等待背景 goroutine 寄出的信
+func (sender *mockEmailSender) waitForCall(t *testing.T) emailCall {
+	t.Helper()
+	select {
+	case call := <-sender.sent:
+		return call
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an email to be sent")
+		return emailCall{}
+	}
+}
+
+func expectUserByEmail(mock sqlmock.Sqlmock, identifier int, email string) {
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE email = \\?").WithArgs(email).
+		WillReturnRows(sqlmock.NewRows(userColumns()).AddRow(identifier, "walter", email, "hash", true, nil, time.Now()))
+}
+
+// expectCreatePasswordReset 對應沒有節流時 CreatePasswordResetContext 的查詢
+func expectCreatePasswordReset(mock sqlmock.Sqlmock, identifier int) {
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE password_resets SET used = TRUE WHERE user_id = \\? AND used = FALSE").WithArgs(identifier).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO password_resets").WithArgs(identifier, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+// expectCreateEmailVerification 對應沒有節流時 CreateEmailVerificationContext 的查詢
+func expectCreateEmailVerification(mock sqlmock.Sqlmock, identifier int) {
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE email_verifications SET used = TRUE WHERE user_id = \\? AND used = FALSE").WithArgs(identifier).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO email_verifications").WithArgs(identifier, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+func newForgotPasswordRouter(t *testing.T, sender *mockEmailSender, throttle time.Duration) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	cfg := testConfig()
+	cfg.Server.PasswordResetThrottle = throttle
+	router := gin.New()
+	router.POST("/forgot-password", ForgotPassword(database, sender, cfg))
+	return router, mock
+}
+
+func assertForgotPasswordResponse(t *testing.T, recorder *httptest.ResponseRecorder) {
+	t.Helper()
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var response map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response["message"] != forgotPasswordMessage {
+		t.Fatalf("expected the generic message, got %q", response["message"])
+	}
+}
+
+func TestForgotPasswordSendsResetEmail(t *testing.T) {
+	sender := newMockEmailSender()
+	router, mock := newForgotPasswordRouter(t, sender, 0)
+
+	expectUserByEmail(mock, 7, "w@w.com")
+	expectCreatePasswordReset(mock, 7)
+
+	assertForgotPasswordResponse(t, performRequest(router, http.MethodPost, "/forgot-password", `{"email":"w@w.com"}`))
+
+	calls := sender.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 email, got %d", len(calls))
+	}
+	if calls[0].method != "SendPasswordResetEmail" || calls[0].to != "w@w.com" || calls[0].value == "" {
+		t.Fatalf("unexpected email call: %+v", calls[0])
+	}
+}
+
+func TestForgotPasswordSendFailureStillReturnsGenericResponse(t *testing.T) {
+	sender := newMockEmailSender()
+	sender.err = errors.New("smtp down")
+	router, mock := newForgotPasswordRouter(t, sender, 0)
+
+	expectUserByEmail(mock, 7, "w@w.com")
+	expectCreatePasswordReset(mock, 7)
+
+	assertForgotPasswordResponse(t, performRequest(router, http.MethodPost, "/forgot-password", `{"email":"w@w.com"}`))
+
+	if calls := sender.Calls(); len(calls) != 1 {
+		t.Fatalf("expected 1 email attempt, got %d", len(calls))
+	}
+}
+
+func TestForgotPasswordUnknownEmailSendsNothing(t *testing.T) {
+	sender := newMockEmailSender()
+	router, mock := newForgotPasswordRouter(t, sender, 0)
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE email = \\?").WithArgs("nobody@example.com").WillReturnError(sql.ErrNoRows)
+
+	assertForgotPasswordResponse(t, performRequest(router, http.MethodPost, "/forgot-password", `{"email":"nobody@example.com"}`))
+
+	if calls := sender.Calls(); len(calls) != 0 {
+		t.Fatalf("expected no email, got %+v", calls)
+	}
+}
+
+func newRegisterRouter(t *testing.T, sender *mockEmailSender) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.POST("/register", Register(database, sender, testConfig()))
+	return router, mock
+}
+
+func TestRegisterSendsWelcomeEmail(t *testing.T) {
+	sender := newMockEmailSender()
+	router, mock := newRegisterRouter(t, sender)
+
+	mock.ExpectExec("INSERT INTO users").WithArgs("walter", "w@w.com", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(7, 1))
+	expectCreateEmailVerification(mock, 7)
+
+	recorder := performRequest(router, http.MethodPost, "/register", `{"username":"walter","email":"w@w.com","password":"12345678"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// 驗證信同步寄出，歡迎信在背景寄出，兩封都要等到
+	calls := map[string]emailCall{}
+	for len(calls) < 2 {
+		call := sender.waitForCall(t)
+		calls[call.method] = call
+	}
+	welcome, found := calls["SendWelcomeEmail"]
+	if !found {
+		t.Fatalf("expected a welcome email, got %+v", calls)
+	}
+	if welcome.to != "w@w.com" || welcome.value != "walter" {
+		t.Fatalf("unexpected welcome email arguments: %+v", welcome)
+	}
+	if verification := calls["SendVerificationEmail"]; verification.to != "w@w.com" || verification.value == "" {
+		t.Fatalf("unexpected verification email arguments: %+v", verification)
+	}
+}
+
+func TestForgotPasswordThrottlesRapidSecondRequest(t *testing.T) {
+	sender := newMockEmailSender()
+	router, mock := newForgotPasswordRouter(t, sender, 2*time.Minute)
+
+	lockUser := "SELECT id FROM users WHERE id = \\? FOR UPDATE"
+	recentReset := "SELECT EXISTS\\(SELECT 1 FROM password_resets WHERE user_id = \\? AND created_at > NOW\\(\\) - INTERVAL \\? SECOND\\)"
+
+	// 第一次：節流時間內沒有 token，建立並寄出
+	expectUserByEmail(mock, 7, "w@w.com")
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockUser).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(recentReset).WithArgs(7, int64(120)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("UPDATE password_resets SET used = TRUE").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO password_resets").WithArgs(7, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// 第二次：剛建立過 token，不再建立也不寄信
+	expectUserByEmail(mock, 7, "w@w.com")
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockUser).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(recentReset).WithArgs(7, int64(120)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	assertForgotPasswordResponse(t, performRequest(router, http.MethodPost, "/forgot-password", `{"email":"w@w.com"}`))
+	assertForgotPasswordResponse(t, performRequest(router, http.MethodPost, "/forgot-password", `{"email":"w@w.com"}`))
+
+	if calls := sender.Calls(); len(calls) != 1 {
+		t.Fatalf("expected exactly 1 email for two rapid requests, got %d", len(calls))
+	}
+}
+
+func TestIssueAccessTokenUsesConfiguredTTL(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_TTL", "1h")
+	serverConfig := config.LoadConfig().Server
+
+	tokenString, err := issueAccessToken(&models.User{ID: 7, Username: "walter"}, serverConfig)
+	if err != nil {
+		t.Fatalf("issueAccessToken: %v", err)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	}); err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		t.Fatalf("expected exp claim, got %v (%v)", expiresAt, err)
+	}
+	if remaining := time.Until(expiresAt.Time); remaining < 59*time.Minute || remaining > time.Hour+time.Second {
+		t.Fatalf("expected exp about 1h from now, got %v", remaining)
+	}
+}
+
+func TestRegisterRejectsInvalidEmail(t *testing.T) {
+	router, _ := newRegisterRouter(t, newMockEmailSender())
+
+	recorder := performRequest(router, http.MethodPost, "/register", `{"username":"walter","email":"not-an-email","password":"12345678"}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if code := decodeAPIError(t, recorder).Code; code != CodeInvalidInput {
+		t.Fatalf("expected %s, got %s", CodeInvalidInput, code)
+	}
+}
+
+func TestRegisterRejectsShortPassword(t *testing.T) {
+	router, _ := newRegisterRouter(t, newMockEmailSender())
+
+	recorder := performRequest(router, http.MethodPost, "/register", `{"username":"walter","email":"w@w.com","password":"abc"}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if code := decodeAPIError(t, recorder).Code; code != CodeInvalidInput {
+		t.Fatalf("expected %s, got %s", CodeInvalidInput, code)
+	}
+}
+
+func TestRegisterSameEmailTwiceReturns409(t *testing.T) {
+	sender := newMockEmailSender()
+	router, mock := newRegisterRouter(t, sender)
+
+	mock.ExpectExec("INSERT INTO users").WithArgs("walter", "w@w.com", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(7, 1))
+	expectCreateEmailVerification(mock, 7)
+	// 第二次違反 users.email 的唯一索引
+	mock.ExpectExec("INSERT INTO users").WithArgs("walter2", "w@w.com", sqlmock.AnyArg()).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'w@w.com' for key 'users.email'"})
+
+	first := performRequest(router, http.MethodPost, "/register", `{"username":"walter","email":"w@w.com","password":"12345678"}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("first registration: expected 200, got %d: %s", first.Code, first.Body.String())
+	}
+	second := performRequest(router, http.MethodPost, "/register", `{"username":"walter2","email":"w@w.com","password":"12345678"}`)
+	if second.Code != http.StatusConflict {
+		t.Fatalf("second registration: expected 409, got %d: %s", second.Code, second.Body.String())
+	}
+	if code := decodeAPIError(t, second).Code; code != CodeEmailTaken {
+		t.Fatalf("expected %s, got %s", CodeEmailTaken, code)
+	}
+
+	// 等背景的歡迎信寄出，避免測試結束後 goroutine 仍在執行
+	for sent := 0; sent < 2; sent++ {
+		sender.waitForCall(t)
+	}
+}
+
+func newResendVerificationRouter(t *testing.T, sender *mockEmailSender) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	cfg := testConfig()
+	cfg.Server.EmailVerificationThrottle = 2 * time.Minute
+	router := gin.New()
+	router.POST("/resend-verification", ResendVerification(database, sender, cfg))
+	return router, mock
+}
+
+func assertResendVerificationResponse(t *testing.T, recorder *httptest.ResponseRecorder) {
+	t.Helper()
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var response map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response["message"] != resendVerificationMessage {
+		t.Fatalf("expected the generic message, got %q", response["message"])
+	}
+}
+
+func TestResendVerificationSendsOnceWithinThrottle(t *testing.T) {
+	sender := newMockEmailSender()
+	router, mock := newResendVerificationRouter(t, sender)
+
+	unverifiedUser := func() {
+		mock.ExpectQuery("SELECT (.+) FROM users WHERE email = \\?").WithArgs("w@w.com").
+			WillReturnRows(sqlmock.NewRows(userColumns()).AddRow(7, "walter", "w@w.com", "hash", false, nil, time.Now()))
+	}
+	lockUser := "SELECT id FROM users WHERE id = \\? FOR UPDATE"
+	recentVerification := "SELECT EXISTS\\(SELECT 1 FROM email_verifications WHERE user_id = \\? AND created_at > NOW\\(\\) - INTERVAL \\? SECOND\\)"
+
+	// 第一次：建立新的驗證 token 並寄出
+	unverifiedUser()
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockUser).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(recentVerification).WithArgs(7, int64(120)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("UPDATE email_verifications SET used = TRUE").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO email_verifications").WithArgs(7, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// 第二次：節流時間內不再寄信
+	unverifiedUser()
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockUser).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(recentVerification).WithArgs(7, int64(120)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	assertResendVerificationResponse(t, performRequest(router, http.MethodPost, "/resend-verification", `{"email":"w@w.com"}`))
+	assertResendVerificationResponse(t, performRequest(router, http.MethodPost, "/resend-verification", `{"email":"w@w.com"}`))
+
+	calls := sender.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected exactly 1 email for two rapid requests, got %d", len(calls))
+	}
+	if calls[0].method != "SendVerificationEmail" || calls[0].to != "w@w.com" || calls[0].value == "" {
+		t.Fatalf("unexpected email: %+v", calls[0])
+	}
+}
+
+func TestResendVerificationSkipsVerifiedAndUnknownEmails(t *testing.T) {
+	sender := newMockEmailSender()
+	router, mock := newResendVerificationRouter(t, sender)
+
+	// expectUserByEmail 回傳已驗證的帳號
+	expectUserByEmail(mock, 7, "w@w.com")
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE email = \\?").WithArgs("nobody@example.com").WillReturnError(sql.ErrNoRows)
+
+	assertResendVerificationResponse(t, performRequest(router, http.MethodPost, "/resend-verification", `{"email":"w@w.com"}`))
+	assertResendVerificationResponse(t, performRequest(router, http.MethodPost, "/resend-verification", `{"email":"nobody@example.com"}`))
+
+	if calls := sender.Calls(); len(calls) != 0 {
+		t.Fatalf("expected no email, got %+v", calls)
+	}
+}
@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Walter1412/micro-backend/db"
+	"github.com/Walter1412/micro-backend/middlewares"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// batchError 帶有要回給客戶端的 HTTP 狀態碼
+type batchError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *batchError) Error() string {
+	return e.message
+}
+
+func newBatchError(status int, format string, args ...interface{}) *batchError {
+	return &batchError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// withCode 指定錯誤代碼，未指定時依狀態碼使用通用代碼
+func (e *batchError) withCode(code string) *batchError {
+	e.code = code
+	return e
+}
+
+// errorCode 回傳錯誤代碼，未指定時依狀態碼使用通用代碼
+func (e *batchError) errorCode() string {
+	if e.code != "" {
+		return e.code
+	}
+	return codeForStatus(e.status)
+}
+
+// batchExecutor 在同一個 transaction 中依序執行批次操作，並記錄 temp_id 對應的真實 ID
+type batchExecutor struct {
+	transaction    *sql.Tx
+	userIdentifier int64
+	tempIDs        map[string]int64
+}
+
+// ExecuteBatch godoc
+// @Summary      批次執行區塊與任務操作
+// @Description  依序執行 create/update/delete/move 操作（section 或 task），全部在同一個 transaction 中完成，任一操作失敗即全部回滾。
+// @Description  建立操作可帶 temp_id，之後的操作可用 ref / section_ref 參照同一批次中建立的資源。
+// @ID           executeBatch
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      models.BatchInput  true  "批次操作"
+// @Success      200   {object}  models.BatchResult
+// @Failure      400   {object}  models.BatchError
+// @Failure      403   {object}  models.BatchError
+// @Failure      404   {object}  models.BatchError
+// @Failure      409   {object}  models.BatchError
+// @Failure      500   {object}  models.BatchError
+// @Router       /plans/batch [post]
+func ExecuteBatch(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		var input models.BatchInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			log.Printf("❌ Invalid input: %v", error)
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
+			return
+		}
+
+		executor := &batchExecutor{
+			userIdentifier: userIdentifier,
+			tempIDs:        make(map[string]int64),
+		}
+
+		results := make([]models.BatchOperationResult, 0, len(input.Operations))
+		failedIndex := -1
+		error := db.WithTransaction(database, func(transaction *sql.Tx) error {
+			executor.transaction = transaction
+			for index, operation := range input.Operations {
+				identifier, error := executor.execute(operation)
+				if error != nil {
+					failedIndex = index
+					log.Printf("❌ Batch operation %d (%s %s) failed for user %d: %v", index, operation.Op, operation.Type, userIdentifier, error)
+					return error
+				}
+
+				if operation.TempID != "" {
+					executor.tempIDs[operation.TempID] = identifier
+				}
+				results = append(results, models.BatchOperationResult{
+					Index:  index,
+					Op:     operation.Op,
+					Type:   operation.Type,
+					TempID: operation.TempID,
+					ID:     identifier,
+				})
+			}
+			return nil
+		})
+		if error != nil {
+			// 不是某個操作失敗，而是 transaction 本身無法開始或提交
+			if failedIndex < 0 {
+				log.Printf("❌ Failed to execute batch transaction: %v", error)
+				RespondError(context, http.StatusInternalServerError, CodeInternal, "Transaction commit failed")
+				return
+			}
+
+			status, code, message := http.StatusInternalServerError, CodeInternal, "Batch operation failed"
+			if batchErr, isValid := error.(*batchError); isValid {
+				status, code, message = batchErr.status, batchErr.errorCode(), batchErr.message
+			}
+			context.JSON(status, models.BatchError{
+				APIError: models.APIError{
+					Code:      code,
+					Message:   message,
+					RequestID: middlewares.RequestIDFromContext(context),
+				},
+				Index: failedIndex,
+			})
+			return
+		}
+
+		log.Printf("✅ Batch executed: %d operations, UserID=%d", len(results), userIdentifier)
+		context.JSON(http.StatusOK, models.BatchResult{Results: results, IDs: executor.tempIDs})
+	}
+}
+
+func (executor *batchExecutor) execute(operation models.BatchOperation) (int64, error) {
+	if operation.TempID != "" {
+		if _, exists := executor.tempIDs[operation.TempID]; exists {
+			return 0, newBatchError(http.StatusBadRequest, "Duplicate temp_id %q", operation.TempID)
+		}
+	}
+
+	switch operation.Type + ":" + operation.Op {
+	case "section:create":
+		return executor.createSection(operation)
+	case "section:update":
+		return executor.updateSection(operation)
+	case "section:delete":
+		return executor.deleteSection(operation)
+	case "section:move":
+		return executor.moveSection(operation)
+	case "task:create":
+		return executor.createTask(operation)
+	case "task:update":
+		return executor.updateTask(operation)
+	case "task:delete":
+		return executor.deleteTask(operation)
+	case "task:move":
+		return executor.moveTask(operation)
+	}
+	return 0, newBatchError(http.StatusBadRequest, "Unsupported operation %s %s", operation.Op, operation.Type)
+}
+
+// resolve 取得操作目標的 ID：優先使用 id，否則以 ref 查詢同批次建立的資源
+func (executor *batchExecutor) resolve(identifier *int64, reference string, field string) (int64, error) {
+	if identifier != nil {
+		return *identifier, nil
+	}
+	if reference != "" {
+		if resolved, exists := executor.tempIDs[reference]; exists {
+			return resolved, nil
+		}
+		return 0, newBatchError(http.StatusBadRequest, "Unknown %s reference %q", field, reference)
+	}
+	return 0, newBatchError(http.StatusBadRequest, "Missing %s", field)
+}
+
+// ownedSection 驗證 section 屬於目前使用者，並回傳是否已關閉
+func (executor *batchExecutor) ownedSection(sectionIdentifier int64) (bool, error) {
+	var ownerIdentifier int64
+	var isClosed bool
+	error := executor.transaction.QueryRow("SELECT user_id, is_closed FROM sections WHERE id = ?", sectionIdentifier).Scan(&ownerIdentifier, &isClosed)
+	if error == sql.ErrNoRows {
+		return false, newBatchError(http.StatusNotFound, "Section %d not found", sectionIdentifier)
+	}
+	if error != nil {
+		return false, error
+	}
+	if ownerIdentifier != executor.userIdentifier {
+		return false, newBatchError(http.StatusForbidden, "Unauthorized section %d", sectionIdentifier)
+	}
+	return isClosed, nil
+}
+
+// ownedTask 透過 join sections 驗證 task 屬於目前使用者，並回傳所屬 section_id
+func (executor *batchExecutor) ownedTask(taskIdentifier int64) (int64, error) {
+	var sectionIdentifier int64
+	var ownerIdentifier int64
+	error := executor.transaction.QueryRow(`
+		SELECT s.id, s.user_id
+		FROM tasks t
+		JOIN sections s ON t.section_id = s.id
+		WHERE t.id = ? AND t.deleted_at IS NULL`, taskIdentifier).Scan(&sectionIdentifier, &ownerIdentifier)
+	if error == sql.ErrNoRows {
+		return 0, newBatchError(http.StatusNotFound, "Task %d not found", taskIdentifier)
+	}
+	if error != nil {
+		return 0, error
+	}
+	if ownerIdentifier != executor.userIdentifier {
+		return 0, newBatchError(http.StatusForbidden, "Unauthorized task %d", taskIdentifier)
+	}
+	return sectionIdentifier, nil
+}
+
+func requireTitle(title *string) (string, error) {
+	if title == nil || *title == "" {
+		return "", newBatchError(http.StatusBadRequest, "Missing title")
+	}
+	return *title, nil
+}
+
+func (executor *batchExecutor) createSection(operation models.BatchOperation) (int64, error) {
+	title, error := requireTitle(operation.Title)
+	if error != nil {
+		return 0, error
+	}
+
+	var maxSort sql.NullInt64
+	if error := executor.transaction.QueryRow("SELECT MAX(sort_order) FROM sections WHERE user_id = ?", executor.userIdentifier).Scan(&maxSort); error != nil {
+		return 0, error
+	}
+
+	result, error := executor.transaction.Exec("INSERT INTO sections (user_id, title, sort_order) VALUES (?, ?, ?)", executor.userIdentifier, title, maxSort.Int64+1)
+	if error != nil {
+		return 0, error
+	}
+	return result.LastInsertId()
+}
+
+func (executor *batchExecutor) updateSection(operation models.BatchOperation) (int64, error) {
+	identifier, error := executor.resolve(operation.ID, operation.Ref, "section id")
+	if error != nil {
+		return 0, error
+	}
+	title, error := requireTitle(operation.Title)
+	if error != nil {
+		return 0, error
+	}
+	if _, error := executor.ownedSection(identifier); error != nil {
+		return 0, error
+	}
+
+	_, error = executor.transaction.Exec("UPDATE sections SET title = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", title, identifier)
+	return identifier, error
+}
+
+func (executor *batchExecutor) deleteSection(operation models.BatchOperation) (int64, error) {
+	identifier, error := executor.resolve(operation.ID, operation.Ref, "section id")
+	if error != nil {
+		return 0, error
+	}
+	if _, error := executor.ownedSection(identifier); error != nil {
+		return 0, error
+	}
+
+	if _, error := executor.transaction.Exec("DELETE FROM sections WHERE id = ?", identifier); error != nil {
+		return 0, error
+	}
+	return identifier, executor.reorderSections(0, 0)
+}
+
+func (executor *batchExecutor) moveSection(operation models.BatchOperation) (int64, error) {
+	identifier, error := executor.resolve(operation.ID, operation.Ref, "section id")
+	if error != nil {
+		return 0, error
+	}
+	if operation.SortOrder == nil {
+		return 0, newBatchError(http.StatusBadRequest, "Missing sort_order")
+	}
+	if _, error := executor.ownedSection(identifier); error != nil {
+		return 0, error
+	}
+	return identifier, executor.reorderSections(identifier, *operation.SortOrder)
+}
+
+func (executor *batchExecutor) createTask(operation models.BatchOperation) (int64, error) {
+	sectionIdentifier, error := executor.resolve(operation.SectionID, operation.SectionRef, "section_id")
+	if error != nil {
+		return 0, error
+	}
+	title, error := requireTitle(operation.Title)
+	if error != nil {
+		return 0, error
+	}
+	isClosed, error := executor.ownedSection(sectionIdentifier)
+	if error != nil {
+		return 0, error
+	}
+	if isClosed {
+		return 0, newBatchError(http.StatusConflict, "Section %d is closed", sectionIdentifier)
+	}
+
+	content := ""
+	if operation.Content != nil {
+		content = *operation.Content
+	}
+	isCompleted := operation.IsCompleted != nil && *operation.IsCompleted
+
+	var maxSort sql.NullInt64
+	if error := executor.transaction.QueryRow("SELECT MAX(sort_order) FROM tasks WHERE section_id = ? AND deleted_at IS NULL", sectionIdentifier).Scan(&maxSort); error != nil {
+		return 0, error
+	}
+
+	result, error := executor.transaction.Exec(`
+		INSERT INTO tasks (user_id, section_id, title, content, is_completed, completed_at, sort_order)
+		VALUES (?, ?, ?, ?, ?, CASE WHEN ? THEN CURRENT_TIMESTAMP ELSE NULL END, ?)`,
+		executor.userIdentifier, sectionIdentifier, title, content, isCompleted, isCompleted, maxSort.Int64+1,
+	)
+	if error != nil {
+		return 0, error
+	}
+	return result.LastInsertId()
+}
+
+func (executor *batchExecutor) updateTask(operation models.BatchOperation) (int64, error) {
+	identifier, error := executor.resolve(operation.ID, operation.Ref, "task id")
+	if error != nil {
+		return 0, error
+	}
+	if _, error := executor.ownedTask(identifier); error != nil {
+		return 0, error
+	}
+
+	// 只更新有帶的欄位
+	if operation.Title != nil {
+		if _, error := executor.transaction.Exec("UPDATE tasks SET title = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", *operation.Title, identifier); error != nil {
+			return 0, error
+		}
+	}
+	if operation.Content != nil {
+		if _, error := executor.transaction.Exec("UPDATE tasks SET content = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", *operation.Content, identifier); error != nil {
+			return 0, error
+		}
+	}
+	if operation.IsCompleted != nil {
+		_, error := executor.transaction.Exec(`
+			UPDATE tasks
+			SET is_completed = ?,
+				completed_at = CASE WHEN ? THEN COALESCE(completed_at, CURRENT_TIMESTAMP) ELSE NULL END,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`, *operation.IsCompleted, *operation.IsCompleted, identifier)
+		if error != nil {
+			return 0, error
+		}
+	}
+	return identifier, nil
+}
+
+func (executor *batchExecutor) deleteTask(operation models.BatchOperation) (int64, error) {
+	identifier, error := executor.resolve(operation.ID, operation.Ref, "task id")
+	if error != nil {
+		return 0, error
+	}
+	sectionIdentifier, error := executor.ownedTask(identifier)
+	if error != nil {
+		return 0, error
+	}
+
+	// 與 DeleteTask 相同採軟刪除
+	if _, error := executor.transaction.Exec("UPDATE tasks SET deleted_at = ? WHERE id = ?", time.Now().UTC(), identifier); error != nil {
+		return 0, error
+	}
+	return identifier, executor.reorderTasks(sectionIdentifier, 0, 0)
+}
+
+func (executor *batchExecutor) moveTask(operation models.BatchOperation) (int64, error) {
+	identifier, error := executor.resolve(operation.ID, operation.Ref, "task id")
+	if error != nil {
+		return 0, error
+	}
+	sourceSectionIdentifier, error := executor.ownedTask(identifier)
+	if error != nil {
+		return 0, error
+	}
+
+	targetSectionIdentifier := sourceSectionIdentifier
+	if operation.SectionID != nil || operation.SectionRef != "" {
+		targetSectionIdentifier, error = executor.resolve(operation.SectionID, operation.SectionRef, "section_id")
+		if error != nil {
+			return 0, error
+		}
+	}
+	isClosed, error := executor.ownedSection(targetSectionIdentifier)
+	if error != nil {
+		return 0, error
+	}
+	if isClosed && targetSectionIdentifier != sourceSectionIdentifier {
+		return 0, newBatchError(http.StatusConflict, "Section %d is closed", targetSectionIdentifier)
+	}
+
+	position := 0
+	if operation.SortOrder != nil {
+		position = *operation.SortOrder
+	}
+
+	if targetSectionIdentifier != sourceSectionIdentifier {
+		if _, error := executor.transaction.Exec("UPDATE tasks SET section_id = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?", targetSectionIdentifier, identifier); error != nil {
+			return 0, error
+		}
+		if error := executor.reorderTasks(sourceSectionIdentifier, 0, 0); error != nil {
+			return 0, error
+		}
+	}
+	return identifier, executor.reorderTasks(targetSectionIdentifier, identifier, position)
+}
+
+// reorderSections 重新編排使用者未封存 section 的 sort_order（從 1 開始連續），已封存的保留原本的 sort_order。
+// movingIdentifier 不為 0 時，會將該 section 放到 position 指定的位置（超出範圍則放到最後）。
+func (executor *batchExecutor) reorderSections(movingIdentifier int64, position int) error {
+	identifiers, error := queryOrderedIdentifiers(executor.transaction, "SELECT id FROM sections WHERE user_id = ? AND archived = FALSE ORDER BY sort_order ASC, id ASC", executor.userIdentifier)
+	if error != nil {
+		return error
+	}
+	for index, identifier := range placeIdentifier(identifiers, movingIdentifier, position) {
+		if _, error := executor.transaction.Exec("UPDATE sections SET sort_order = ?, version = version + 1 WHERE id = ?", index+1, identifier); error != nil {
+			return error
+		}
+	}
+	return nil
+}
+
+// reorderTasks 與 reorderSections 相同，但作用於單一 section 內的 tasks
+func (executor *batchExecutor) reorderTasks(sectionIdentifier int64, movingIdentifier int64, position int) error {
+	identifiers, error := queryOrderedIdentifiers(executor.transaction, "SELECT id FROM tasks WHERE section_id = ? AND deleted_at IS NULL ORDER BY sort_order ASC, id ASC", sectionIdentifier)
+	if error != nil {
+		return error
+	}
+	for index, identifier := range placeIdentifier(identifiers, movingIdentifier, position) {
+		if _, error := executor.transaction.Exec("UPDATE tasks SET sort_order = ?, version = version + 1 WHERE id = ?", index+1, identifier); error != nil {
+			return error
+		}
+	}
+	return nil
+}
+
+func queryOrderedIdentifiers(transaction *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+	rows, error := transaction.Query(query, args...)
+	if error != nil {
+		return nil, error
+	}
+	defer rows.Close()
+
+	var identifiers []int64
+	for rows.Next() {
+		var identifier int64
+		if error := rows.Scan(&identifier); error != nil {
+			return nil, error
+		}
+		identifiers = append(identifiers, identifier)
+	}
+	return identifiers, rows.Err()
+}
+
+// placeIdentifier 將 movingIdentifier 移到第 position 個位置（1 起算），position 無效時放到最後
+func placeIdentifier(identifiers []int64, movingIdentifier int64, position int) []int64 {
+	if movingIdentifier == 0 {
+		return identifiers
+	}
+
+	remaining := make([]int64, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		if identifier != movingIdentifier {
+			remaining = append(remaining, identifier)
+		}
+	}
+
+	if position < 1 || position > len(remaining)+1 {
+		position = len(remaining) + 1
+	}
+	placed := make([]int64, 0, len(remaining)+1)
+	placed = append(placed, remaining[:position-1]...)
+	placed = append(placed, movingIdentifier)
+	return append(placed, remaining[position-1:]...)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func newBatchRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.POST("/plans/batch", withUser(1), ExecuteBatch(database))
+	return router, mock
+}
+
+func TestExecuteBatchDeleteSectionReordersOnlyActiveSections(t *testing.T) {
+	router, mock := newBatchRouter(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, is_closed FROM sections WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "is_closed"}).AddRow(1, false))
+	mock.ExpectExec("DELETE FROM sections WHERE id = \\?").WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	// 已封存的 section 不參與重排，保留原本的 sort_order
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM sections WHERE user_id = ? AND archived = FALSE ORDER BY sort_order ASC, id ASC")).WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(4))
+	mock.ExpectExec("UPDATE sections SET sort_order = \\?").WithArgs(1, int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE sections SET sort_order = \\?").WithArgs(2, int64(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	recorder := performRequest(router, http.MethodPost, "/plans/batch", `{"operations":[{"op":"delete","type":"section","id":3}]}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestExecuteBatchFailureUsesErrorEnvelopeWithIndex(t *testing.T) {
+	router, mock := newBatchRouter(t)
+
+	// 第二個操作失敗，整批回滾並指出失敗的位置
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, is_closed FROM sections WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "is_closed"}).AddRow(1, false))
+	mock.ExpectExec("UPDATE sections SET title = \\?").WithArgs("Renamed", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT user_id, is_closed FROM sections WHERE id = \\?").WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "is_closed"}).AddRow(2, false))
+	mock.ExpectRollback()
+
+	recorder := performRequest(router, http.MethodPost, "/plans/batch",
+		`{"operations":[{"op":"update","type":"section","id":3,"title":"Renamed"},{"op":"delete","type":"section","id":4}]}`)
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response models.BatchError
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Code != CodeForbidden || response.Message != "Unauthorized section 4" || response.Index != 1 {
+		t.Fatalf("unexpected error response: %+v", response)
+	}
+}
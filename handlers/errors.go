@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Walter1412/micro-backend/middlewares"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// 錯誤代碼，前端依此判斷錯誤種類；新增代碼時只能追加，不可修改既有的值
+const (
+	CodeInvalidInput       = "INVALID_INPUT"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeConflict           = "CONFLICT"
+	CodeTooManyRequests    = "TOO_MANY_REQUESTS"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+	CodeEmailNotVerified   = "EMAIL_NOT_VERIFIED"
+	CodeEmailTaken         = "EMAIL_TAKEN"
+	CodeUsernameTaken      = "USERNAME_TAKEN"
+	CodeUserNotFound       = "USER_NOT_FOUND"
+	CodeInvalidToken       = "INVALID_TOKEN"
+	CodeSectionNotFound    = "SECTION_NOT_FOUND"
+	CodeSectionClosed      = "SECTION_CLOSED"
+	CodeTaskNotFound       = "TASK_NOT_FOUND"
+	CodePlanModified       = "PLAN_MODIFIED"
+	CodeRequestTimeout     = "REQUEST_TIMEOUT"
+)
+
+// RespondError 以 models.APIError 格式回傳錯誤並附上 request ID，HTTP 狀態碼由呼叫端決定
+func RespondError(context *gin.Context, status int, code string, message string) {
+	context.JSON(status, models.APIError{
+		Code:      code,
+		Message:   message,
+		RequestID: middlewares.RequestIDFromContext(context),
+	})
+}
+
+// respondServerError 回傳 500；請求已超過 TimeoutMiddleware 設定的期限時改回傳 503
+func respondServerError(context *gin.Context, message string) {
+	if middlewares.RequestTimedOut(context) {
+		RespondError(context, http.StatusServiceUnavailable, CodeRequestTimeout, "request timeout")
+		return
+	}
+	RespondError(context, http.StatusInternalServerError, CodeInternal, message)
+}
+
+// serverErrorStatus 為不回傳內容的端點（例如 HEAD）選擇 500 或逾時的 503
+func serverErrorStatus(context *gin.Context) int {
+	if middlewares.RequestTimedOut(context) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// codeForStatus 為沒有指定代碼的錯誤依狀態碼選擇通用代碼
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidInput
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	default:
+		return CodeInternal
+	}
+}
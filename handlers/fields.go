@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Walter1412/micro-backend/models"
+)
+
+// 允許的欄位直接取自 model 的 json 標籤，新增欄位時不必另外維護清單
+var (
+	sectionFieldAllowlist = jsonFieldNames(reflect.TypeOf(models.Section{}))
+	taskFieldAllowlist    = jsonFieldNames(reflect.TypeOf(models.Task{}))
+)
+
+func jsonFieldNames(structType reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name := range jsonFieldNames(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// parseFields 解析 ?fields=a,b,c；未指定時回傳 nil（表示回傳全部欄位）
+func parseFields(raw string, allowlist map[string]bool) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !allowlist[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// pickFields 在序列化層只保留指定欄位（items 必須是 slice），fields 為空時原樣回傳
+func pickFields(items interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return items, nil
+	}
+
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var decoded []map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+
+	picked := make([]map[string]json.RawMessage, len(decoded))
+	for index, item := range decoded {
+		picked[index] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, exists := item[field]; exists {
+				picked[index][field] = value
+			}
+		}
+	}
+	return picked, nil
+}
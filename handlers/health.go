@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout 為 readiness 檢查 Ping DB 的上限時間
+const healthCheckTimeout = 2 * time.Second
+
+// Healthz 檢查服務與 DB 是否可用，供 load balancer / k8s readiness probe 使用。
+// 掛在 API 前綴之外且不需登入，因此不列入 Swagger 文件。
+func Healthz(database *sql.DB) gin.HandlerFunc {
+	return func(ginContext *gin.Context) {
+		pingContext, cancel := context.WithTimeout(ginContext.Request.Context(), healthCheckTimeout)
+		defer cancel()
+
+		if error := database.PingContext(pingContext); error != nil {
+			log.Printf("❌ Health check failed: %v", error)
+			ginContext.JSON(http.StatusServiceUnavailable, gin.H{"status": "degraded", "db": "down"})
+			return
+		}
+		ginContext.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// Livez 只表示程序仍在執行，不檢查 DB，供 liveness probe 使用
+func Livez() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// Version 回傳目前提供的 API 版本與各版本的路徑前綴，讓掛在 gateway 後面的 client 知道要呼叫哪個前綴。
+// 與 health check 一樣掛在根目錄且不需登入，因此不列入 Swagger 文件。
+func Version(apiVersions map[string]string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.JSON(http.StatusOK, gin.H{"api_versions": apiVersions})
+	}
+}
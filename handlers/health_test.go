@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVersionReportsAPIVersionPaths(t *testing.T) {
+	router := gin.New()
+	router.GET("/version", Version(map[string]string{"v1": "/todo/api/v1", "v2": "/todo/api/v2"}))
+
+	recorder := performRequest(router, http.MethodGet, "/version", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response struct {
+		APIVersions map[string]string `json:"api_versions"`
+	}
+	if error := json.Unmarshal(recorder.Body.Bytes(), &response); error != nil {
+		t.Fatalf("failed to decode response: %v", error)
+	}
+	if response.APIVersions["v1"] != "/todo/api/v1" || response.APIVersions["v2"] != "/todo/api/v2" {
+		t.Fatalf("unexpected api_versions: %v", response.APIVersions)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Walter1412/micro-backend/config"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newMockDB 建立 sqlmock 連線，測試結束時確認所有預期的查詢都有執行
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	database, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		database.Close()
+	})
+	return database, mock
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{
+			JWTSecret:      "test-secret",
+			AccessTokenTTL: time.Hour,
+			BcryptCost:     4,
+		},
+	}
+}
+
+// withUser 模擬 JWTAuthMiddleware 設定的 user_id
+func withUser(userIdentifier int64) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Set("user_id", userIdentifier)
+		context.Next()
+	}
+}
+
+func performRequest(router http.Handler, method string, path string, body string) *httptest.ResponseRecorder {
+	var request *http.Request
+	if body == "" {
+		request = httptest.NewRequest(method, path, nil)
+	} else {
+		request = httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decodeAPIError(t *testing.T, recorder *httptest.ResponseRecorder) models.APIError {
+	t.Helper()
+	var apiError models.APIError
+	if err := json.Unmarshal(recorder.Body.Bytes(), &apiError); err != nil {
+		t.Fatalf("failed to decode error response %q: %v", recorder.Body.String(), err)
+	}
+	return apiError
+}
+
+func userColumns() []string {
+	return []string{"id", "username", "email", "password_hash", "is_verified", "last_login_at", "created_at"}
+}
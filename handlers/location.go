@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resourceLocation 以目前的集合路由（例如 /api/v1/plans/sections）組出新資源的 URL，
+// 讓 Location header 跟著實際掛載的路徑走
+func resourceLocation(context *gin.Context, identifier int64) string {
+	return context.FullPath() + "/" + strconv.FormatInt(identifier, 10)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// parsePagination 讀取 ?page= 與 ?page_size=（page 從 1 起算）
+func parsePagination(context *gin.Context) (int, int, error) {
+	page := 1
+	pageSize := defaultPageSize
+
+	if raw := context.Query("page"); raw != "" {
+		value, error := strconv.Atoi(raw)
+		if error != nil || value < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+		page = value
+	}
+	if raw := context.Query("page_size"); raw != "" {
+		value, error := strconv.Atoi(raw)
+		if error != nil || value < 1 || value > maxPageSize {
+			return 0, 0, errors.New("page_size must be between 1 and 200")
+		}
+		pageSize = value
+	}
+	return page, pageSize, nil
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportPlans godoc
+// @Summary      匯出計畫
+// @Description  以 JSON 檔下載本人所有區塊（含已封存）與任務（含延後中，不含已刪除），格式附上 version 供日後匯入時轉換；沒有任何區塊時 sections 為空陣列
+// @ID           exportPlans
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.PlanExport
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/export [get]
+func ExportPlans(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		sections, error := loadSectionTree(context, database, userIdentifier, taskTreeFilter{IncludeDeferred: true}, true)
+		if error != nil {
+			log.Printf("❌ Failed to load plans to export: %v", error)
+			respondServerError(context, "Failed to export plans")
+			return
+		}
+
+		log.Printf("✅ Plans exported: Sections=%d, UserID=%d", len(sections), userIdentifier)
+		context.Header("Content-Disposition", `attachment; filename="plans-export.json"`)
+		context.IndentedJSON(http.StatusOK, models.PlanExport{
+			Version:    models.PlanExportVersion,
+			ExportedAt: time.Now().UTC(),
+			Sections:   sections,
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Walter1412/micro-backend/db"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	importModeAppend  = "append"
+	importModeReplace = "replace"
+)
+
+// ImportPlans godoc
+// @Summary      匯入計畫
+// @Description  以 /plans/export 的格式還原備份，在同一個 transaction 中建立新的區塊與任務（使用新的 ID，sort_order 依檔案順序重新編號）。
+// @Description  預設 append 會接在現有區塊之後；mode=replace 會先刪除本人所有區塊與任務再匯入。version 不是目前支援的版本時回傳 400
+// @ID           importPlans
+// @Tags         Plans
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        mode  query  string             false  "append（預設）或 replace"  Enums(append, replace)
+// @Param        body  body   models.PlanExport  true   "匯出的備份內容"
+// @Success      201   {object}  models.PlanImportResult
+// @Failure      400   {object}  models.APIError
+// @Failure      409   {object}  models.APIError
+// @Failure      500   {object}  models.APIError
+// @Router       /plans/import [post]
+func ImportPlans(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		mode := context.DefaultQuery("mode", importModeAppend)
+		if mode != importModeAppend && mode != importModeReplace {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "mode must be append or replace")
+			return
+		}
+
+		var input models.PlanExport
+		if error := context.ShouldBindJSON(&input); error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
+			return
+		}
+		if input.Version != models.PlanExportVersion {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, fmt.Sprintf("Unsupported export version %d", input.Version))
+			return
+		}
+		if error := validateImport(input.Sections); error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, error.Error())
+			return
+		}
+
+		result := models.PlanImportResult{Mode: mode}
+		error := db.WithTransaction(database, func(transaction *sql.Tx) error {
+			if mode == importModeReplace {
+				// task_labels 隨 tasks 以 ON DELETE CASCADE 一併刪除
+				for _, statement := range []string{
+					"DELETE FROM tasks WHERE user_id = ?",
+					"DELETE FROM section_snapshots WHERE user_id = ?",
+					"DELETE FROM sections WHERE user_id = ?",
+				} {
+					if _, error := transaction.Exec(statement, userIdentifier); error != nil {
+						return error
+					}
+				}
+			}
+
+			// ✅ 鎖定使用者現有區塊，避免同時建立時 sort_order 重複
+			var maxSort sql.NullInt64
+			if error := transaction.QueryRow("SELECT MAX(sort_order) FROM sections WHERE user_id = ? FOR UPDATE", userIdentifier).Scan(&maxSort); error != nil {
+				return error
+			}
+			nextSort := int(maxSort.Int64) + 1
+
+			for sectionIndex, section := range input.Sections {
+				inserted, error := transaction.Exec(
+					"INSERT INTO sections (user_id, title, sort_order, is_closed, archived) VALUES (?, ?, ?, ?, ?)",
+					userIdentifier, strings.TrimSpace(section.Title), nextSort, section.IsClosed, section.IsArchived,
+				)
+				if error != nil {
+					return error
+				}
+				sectionIdentifier, _ := inserted.LastInsertId()
+				nextSort++
+				result.Sections++
+
+				for taskIndex, task := range section.Tasks {
+					externalRef := normalizeExternalRef(task.ExternalRef)
+					if error := models.ReleaseTrashedExternalRef(transaction, userIdentifier, externalRef); error != nil {
+						return error
+					}
+
+					_, error := transaction.Exec(`
+						INSERT INTO tasks (user_id, section_id, title, content, external_ref, due_date, deferred_until, priority, is_completed, completed_at, sort_order)
+						VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CASE WHEN ? THEN CURRENT_TIMESTAMP ELSE NULL END, ?)`,
+						userIdentifier, sectionIdentifier, task.Title, task.Content, externalRef, utcTime(task.DueDate), utcTime(task.DeferredUntil),
+						taskPriority(task.Priority), task.IsCompleted, task.IsCompleted, taskIndex+1,
+					)
+					if models.IsDuplicateEntry(error) {
+						return newBatchError(http.StatusConflict, "sections[%d].tasks[%d].external_ref already used by another task", sectionIndex, taskIndex)
+					}
+					if error != nil {
+						return error
+					}
+					result.Tasks++
+				}
+			}
+			return nil
+		})
+		if error != nil {
+			if batchErr, isValid := error.(*batchError); isValid {
+				RespondError(context, batchErr.status, batchErr.errorCode(), batchErr.message)
+				return
+			}
+			log.Printf("❌ Failed to import plans: %v", error)
+			respondServerError(context, "Failed to import plans")
+			return
+		}
+
+		log.Printf("✅ Plans imported: Mode=%s, Sections=%d, Tasks=%d, UserID=%d", mode, result.Sections, result.Tasks, userIdentifier)
+		context.JSON(http.StatusCreated, result)
+	}
+}
+
+// validateImport 在寫入前檢查整份備份，避免匯入到一半才失敗
+func validateImport(sections []models.SectionWithTasks) error {
+	for sectionIndex, section := range sections {
+		title := strings.TrimSpace(section.Title)
+		if title == "" || utf8.RuneCountInString(title) > maxSectionTitleLength {
+			return fmt.Errorf("sections[%d].title must be 1 to %d characters", sectionIndex, maxSectionTitleLength)
+		}
+		for taskIndex, task := range section.Tasks {
+			if strings.TrimSpace(task.Title) == "" {
+				return fmt.Errorf("sections[%d].tasks[%d].title is required", sectionIndex, taskIndex)
+			}
+			if task.Priority != "" && !isValidTaskPriority(task.Priority) {
+				return fmt.Errorf("sections[%d].tasks[%d].priority must be low, medium or high", sectionIndex, taskIndex)
+			}
+		}
+	}
+	return nil
+}
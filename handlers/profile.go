@@ -1,38 +1,228 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 
+	"github.com/Walter1412/micro-backend/middlewares"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/Walter1412/micro-backend/services"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Profile godoc
 // @Summary      取得個人資訊
-// @Description  使用 JWT 取得當前登入者資訊
-// @Tags         user
+// @Description  使用 JWT 取得當前登入者資訊，資料由 LoadUserMiddleware 從 DB 載入並短暫快取；token 簽發後帳號已刪除時回傳 404
+// @ID           getProfile
+// @Tags         User
 // @Security     BearerAuth
 // @Produce      json
-// @Success      200 {object} map[string]interface{}
+// @Success      200 {object} models.UserProfile
 // @Failure      401 {object} map[string]string
+// @Failure      404 {object} models.APIError
+// @Failure      500 {object} map[string]string
 // @Router       /profile [get]
 func Profile() gin.HandlerFunc {
 	return func(context *gin.Context) {
-		userIdentifier, exists := context.Get("user_id")
-		if !exists {
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "user_id not found"})
+		user, isLoaded := currentUser(context, "Failed to load profile")
+		if !isLoaded {
 			return
 		}
-		username, exists := context.Get("username")
-		if !exists {
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "username not found"})
+
+		context.JSON(http.StatusOK, models.UserProfile{
+			UserID:      user.ID,
+			Username:    user.Username,
+			Email:       user.Email,
+			CreatedAt:   user.CreatedAt,
+			LastLoginAt: user.LastLoginAt,
+			Message:     "You are authenticated!",
+		})
+	}
+}
+
+// UpdateProfile godoc
+// @Summary      更新個人資訊
+// @Description  修改目前登入者的 username 與 email。更換 email 後帳號會回到未驗證狀態並寄出新的驗證信，驗證後才能再次登入
+// @ID           updateProfile
+// @Tags         User
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        user  body  models.UpdateUserInput  true  "個人資訊"
+// @Success      200 {object} models.UserProfile
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} models.APIError
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /profile [put]
+func UpdateProfile(database *sql.DB, emailService services.EmailSender, userCache *middlewares.UserCache) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var input models.UpdateUserInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": validationErrorMessage(error)})
+			return
+		}
+		username := strings.TrimSpace(input.Username)
+		if username == "" {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "username is required"})
+			return
+		}
+
+		current, isLoaded := currentUser(context, "Failed to update profile")
+		if !isLoaded {
 			return
 		}
+		// 快取中的資料與其他請求共用，修改前先複製
+		user := *current
 
-		// ✅ 實務上可以從 DB 查 user 資料，這邊簡化直接回傳 ID
-		context.JSON(http.StatusOK, gin.H{
-			"user_id":  userIdentifier,
-			"username": username,
-			"message":  "You are authenticated!",
+		emailChanged := !strings.EqualFold(user.Email, input.Email)
+		user.Username = username
+		user.Email = input.Email
+		if emailChanged {
+			user.IsVerified = false
+		}
+
+		// users.email / users.username 有唯一索引，重複時 UpdateUser 會回傳對應的錯誤
+		if error := models.UpdateUser(database, &user); error != nil {
+			if errors.Is(error, models.ErrDuplicateEmail) {
+				context.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+				return
+			}
+			if errors.Is(error, models.ErrDuplicateUsername) {
+				context.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+				return
+			}
+			log.Printf("❌ Failed to update user %d: %v", user.ID, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+		userCache.Evict(int64(user.ID))
+
+		message := "Profile updated"
+		if emailChanged {
+			message = "Profile updated, please verify your new email"
+			// ✅ 資料已更新，驗證信失敗只記錄錯誤，使用者之後可透過 POST /resend-verification 重新申請；
+			// 建立新 token 時寄到舊 email 的連結會一併失效
+			verification, error := models.CreateEmailVerificationContext(context.Request.Context(), database, user.ID, 0)
+			if error != nil {
+				log.Printf("❌ Failed to create email verification for user %d: %v", user.ID, error)
+			} else if error := emailService.SendVerificationEmail(user.Email, verification.Token); error != nil {
+				log.Printf("❌ Failed to send verification email to user %d: %v", user.ID, error)
+			}
+		}
+
+		log.Printf("✅ Profile updated: UserID=%d, EmailChanged=%t", user.ID, emailChanged)
+		context.JSON(http.StatusOK, models.UserProfile{
+			UserID:      user.ID,
+			Username:    user.Username,
+			Email:       user.Email,
+			CreatedAt:   user.CreatedAt,
+			LastLoginAt: user.LastLoginAt,
+			Message:     message,
 		})
 	}
 }
+
+// DeleteAccount godoc
+// @Summary      刪除帳號
+// @Description  確認目前密碼後，刪除目前登入者的帳號與所有區塊、任務等資料，無法復原
+// @ID           deleteAccount
+// @Tags         User
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  models.DeleteUserInput  true  "目前密碼"
+// @Success      204
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} models.APIError
+// @Failure      500 {object} map[string]string
+// @Router       /profile [delete]
+func DeleteAccount(database *sql.DB, userCache *middlewares.UserCache) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var input models.DeleteUserInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": validationErrorMessage(error)})
+			return
+		}
+
+		user, isLoaded := currentUser(context, "Failed to delete account")
+		if !isLoaded {
+			return
+		}
+
+		if error := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); error != nil {
+			context.JSON(http.StatusForbidden, gin.H{"error": "Incorrect password"})
+			return
+		}
+
+		if error := models.DeleteUser(database, user.ID); error != nil {
+			log.Printf("❌ Failed to delete user %d: %v", user.ID, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+			return
+		}
+		userCache.Evict(int64(user.ID))
+
+		log.Printf("✅ Account deleted: UserID=%d", user.ID)
+		context.Status(http.StatusNoContent)
+	}
+}
+
+// currentUser 取得 LoadUserMiddleware 載入的使用者，路由沒有掛該中介層時回傳 500
+func currentUser(context *gin.Context, message string) (*models.User, bool) {
+	user, isLoaded := middlewares.CurrentUser(context)
+	if !isLoaded {
+		log.Printf("❌ Current user not loaded for %s, LoadUserMiddleware is not mounted", context.FullPath())
+		context.JSON(http.StatusInternalServerError, gin.H{"error": message})
+		return nil, false
+	}
+	return user, true
+}
+
+// GetFootprint godoc
+// @Summary      取得個人資料使用量
+// @Description  回傳使用者的區塊、任務、區塊快照與任務標籤數量，以及以文字欄位長度估算的資料大小（僅供參考）
+// @ID           getFootprint
+// @Tags         User
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.DataFootprint
+// @Failure      500 {object} models.APIError
+// @Failure      503 {object} models.APIError
+// @Router       /profile/footprint [get]
+func GetFootprint(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+		requestContext := context.Request.Context()
+
+		var footprint models.DataFootprint
+		buckets := []struct {
+			name     string
+			query    string
+			resource *models.ResourceFootprint
+		}{
+			{"section", "SELECT COUNT(*), COALESCE(SUM(LENGTH(title)), 0) FROM sections WHERE user_id = ?", &footprint.Sections},
+			{"task", "SELECT COUNT(*), COALESCE(SUM(LENGTH(title) + LENGTH(content)), 0) FROM tasks WHERE user_id = ?", &footprint.Tasks},
+			{"section snapshot", "SELECT COUNT(*), COALESCE(SUM(LENGTH(tasks)), 0) FROM section_snapshots WHERE user_id = ?", &footprint.SectionSnapshots},
+			{"task label", "SELECT COUNT(*), COALESCE(SUM(LENGTH(tl.label)), 0) FROM task_labels tl JOIN tasks t ON t.id = tl.task_id WHERE t.user_id = ?", &footprint.TaskLabels},
+		}
+		for _, bucket := range buckets {
+			error := database.QueryRowContext(requestContext, bucket.query, userIdentifier).Scan(&bucket.resource.Count, &bucket.resource.ApproxBytes)
+			if error != nil {
+				log.Printf("❌ Failed to compute %s footprint: %v", bucket.name, error)
+				respondServerError(context, "Failed to compute footprint")
+				return
+			}
+		}
+
+		footprint.TotalBytes = footprint.Sections.ApproxBytes + footprint.Tasks.ApproxBytes +
+			footprint.SectionSnapshots.ApproxBytes + footprint.TaskLabels.ApproxBytes
+		context.JSON(http.StatusOK, footprint)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Walter1412/micro-backend/middlewares"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateProfileKeepsLastLoginAt(t *testing.T) {
+	database, mock := newMockDB(t)
+	userCache := middlewares.NewUserCache(database, time.Minute)
+	router := gin.New()
+	router.PUT("/profile", withUser(7), middlewares.LoadUserMiddleware(userCache), UpdateProfile(database, newMockEmailSender(), userCache))
+
+	lastLoginAt := time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE id = \\?").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(userColumns()).AddRow(7, "walter", "w@w.com", "hash", true, lastLoginAt, time.Now()))
+	mock.ExpectExec("UPDATE users SET username = \\?, email = \\?, is_verified = \\? WHERE id = \\?").
+		WithArgs("walter2", "w@w.com", true, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	recorder := performRequest(router, http.MethodPut, "/profile", `{"username":"walter2","email":"w@w.com"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var profile models.UserProfile
+	if err := json.Unmarshal(recorder.Body.Bytes(), &profile); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if profile.LastLoginAt == nil || !profile.LastLoginAt.Equal(lastLoginAt) {
+		t.Fatalf("expected last_login_at %v, got %v", lastLoginAt, profile.LastLoginAt)
+	}
+}
+
+func TestGetFootprintIncludesSnapshotsAndLabels(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/profile/footprint", withUser(7), GetFootprint(database))
+
+	footprintRows := func(count int, bytes int64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"count", "bytes"}).AddRow(count, bytes)
+	}
+	mock.ExpectQuery("FROM sections WHERE user_id = \\?").WithArgs(7).WillReturnRows(footprintRows(2, 20))
+	mock.ExpectQuery("FROM tasks WHERE user_id = \\?").WithArgs(7).WillReturnRows(footprintRows(3, 300))
+	mock.ExpectQuery("SUM\\(LENGTH\\(tasks\\)\\), 0\\) FROM section_snapshots WHERE user_id = \\?").WithArgs(7).WillReturnRows(footprintRows(1, 1000))
+	mock.ExpectQuery("SUM\\(LENGTH\\(tl.label\\)\\), 0\\) FROM task_labels tl JOIN tasks t ON t.id = tl.task_id WHERE t.user_id = \\?").WithArgs(7).
+		WillReturnRows(footprintRows(4, 16))
+
+	recorder := performRequest(router, http.MethodGet, "/profile/footprint", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var footprint models.DataFootprint
+	if err := json.Unmarshal(recorder.Body.Bytes(), &footprint); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if footprint.SectionSnapshots != (models.ResourceFootprint{Count: 1, ApproxBytes: 1000}) {
+		t.Fatalf("unexpected section_snapshots footprint: %+v", footprint.SectionSnapshots)
+	}
+	if footprint.TaskLabels != (models.ResourceFootprint{Count: 4, ApproxBytes: 16}) {
+		t.Fatalf("unexpected task_labels footprint: %+v", footprint.TaskLabels)
+	}
+	if footprint.TotalBytes != 1336 {
+		t.Fatalf("expected total_bytes 1336, got %d", footprint.TotalBytes)
+	}
+}
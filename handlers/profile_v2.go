@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileV2 為 /api/v2/profile：拿掉 v1 為相容保留的 message，並加上驗證狀態與計畫統計。
+// Swagger 文件目前只涵蓋 v1（BasePath 為 /api/v1），因此這裡不加 swag 註解。
+func ProfileV2(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		user, error := models.GetUserByID(database, int(userIdentifier))
+		if errors.Is(error, sql.ErrNoRows) {
+			RespondError(context, http.StatusNotFound, CodeUserNotFound, "User not found")
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to load user %d: %v", userIdentifier, error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to load profile")
+			return
+		}
+
+		profile := models.UserProfileV2{
+			UserID:      user.ID,
+			Username:    user.Username,
+			Email:       user.Email,
+			IsVerified:  user.IsVerified,
+			CreatedAt:   user.CreatedAt,
+			LastLoginAt: user.LastLoginAt,
+		}
+		error = database.QueryRow(`
+			SELECT
+				(SELECT COUNT(*) FROM sections WHERE user_id = ? AND archived = FALSE),
+				COUNT(*),
+				COALESCE(SUM(is_completed), 0)
+			FROM tasks
+			WHERE user_id = ? AND deleted_at IS NULL`, userIdentifier, userIdentifier).Scan(&profile.Stats.Sections, &profile.Stats.Tasks, &profile.Stats.CompletedTasks)
+		if error != nil {
+			log.Printf("❌ Failed to load profile stats for user %d: %v", userIdentifier, error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to load profile")
+			return
+		}
+
+		context.JSON(http.StatusOK, profile)
+	}
+}
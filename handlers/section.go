@@ -2,42 +2,57 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Walter1412/micro-backend/db"
 	"github.com/Walter1412/micro-backend/models"
 	"github.com/gin-gonic/gin"
 )
 
 // CreateSection godoc
 // @Summary      建立新區塊（Section）
-// @Description  建立一個新的區塊（自動補上 sort_order）
+// @Description  建立一個新的區塊（自動補上 sort_order），回傳格式與列表中的元素相同；有帶 client_id 時會一併回傳
+// @ID           createSection
 // @Tags         Plans
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        section  body  models.CreateSectionInput  true  "區塊資料"
-// @Success      200      {object}  map[string]interface{}
-// @Failure      400,500  {object}  map[string]string
+// @Success      201      {object}  models.Section
+// @Header       201      {string}  Location  "新區塊的 URL"
+// @Failure      400,500  {object}  models.APIError
 // @Router       /plans/sections [post]
 func CreateSection(database *sql.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		var input models.CreateSectionInput
 		if error := context.ShouldBindJSON(&input); error != nil {
 			log.Printf("❌ Invalid input: %v", error)
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
 			return
 		}
 
 		userIdentifier := context.GetInt64("user_id") // 🔐 確保是 int64，避免型別問題
 
-		// ✅ 取得目前使用者的最大 sort_order
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "DB transaction error")
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 取得目前使用者的最大 sort_order，並鎖定使用者現有區塊，避免同時建立時 sort_order 重複
 		var maxSort sql.NullInt64
-		error := database.QueryRow("SELECT MAX(sort_order) FROM sections WHERE user_id = ?", userIdentifier).Scan(&maxSort)
+		error = transaction.QueryRow("SELECT MAX(sort_order) FROM sections WHERE user_id = ? FOR UPDATE", userIdentifier).Scan(&maxSort)
 		if error != nil {
 			log.Printf("❌ Failed to query max sort: %v", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get max sort"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to get max sort")
 			return
 		}
 
@@ -49,46 +64,194 @@ func CreateSection(database *sql.DB) gin.HandlerFunc {
 		log.Printf("🧪 Creating section: user_id=%d, title=%s, sort_order=%d", userIdentifier, input.Title, newSort)
 
 		// ✅ 插入資料
-		result, error := database.Exec("INSERT INTO sections (user_id, title, sort_order) VALUES (?, ?, ?)", userIdentifier, input.Title, newSort)
+		result, error := transaction.Exec("INSERT INTO sections (user_id, title, sort_order) VALUES (?, ?, ?)", userIdentifier, input.Title, newSort)
 		if error != nil {
 			log.Printf("❌ Failed to insert section: %v", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create section"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to create section")
 			return
 		}
 
 		insertedIdentifier, _ := result.LastInsertId()
+
+		// ✅ 重新查詢剛建立的資料，讓回傳格式與 GetSections 一致
+		response := struct {
+			models.Section
+			ClientID string `json:"client_id,omitempty"`
+		}{ClientID: input.ClientID}
+		section := &response.Section
+		error = transaction.QueryRow(`
+			SELECT id, title, sort_order, is_closed, archived, version, created_at, updated_at
+			FROM sections
+			WHERE id = ?`, insertedIdentifier).
+			Scan(&section.ID, &section.Title, &section.SortOrder, &section.IsClosed, &section.IsArchived, &section.Version, &section.CreatedAt, &section.UpdatedAt)
+		if error != nil {
+			log.Printf("❌ Failed to query created section: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to fetch created section")
+			return
+		}
+
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Commit failed")
+			return
+		}
 		log.Printf("✅ Section created: ID=%d, Title=%s, Sort=%d, UserID=%d", insertedIdentifier, input.Title, newSort, userIdentifier)
 
-		context.JSON(http.StatusOK, gin.H{
-			"id":      insertedIdentifier,
-			"title":   input.Title,
-			"sort":    newSort,
-			"user_id": userIdentifier,
-		})
+		context.Header("Location", resourceLocation(context, insertedIdentifier))
+		context.JSON(http.StatusCreated, response)
+	}
+}
+
+// maxBulkSections 為單次批次建立區塊的上限
+const maxBulkSections = 100
+
+// BulkCreateSections godoc
+// @Summary      批次建立區塊（Section）
+// @Description  一次建立多個區塊，依陣列順序接在使用者現有區塊之後，全部在同一個 transaction 中完成，任一筆失敗即全部回滾（單次最多 100 筆）
+// @ID           bulkCreateSections
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        sections  body      []models.CreateSectionInput  true  "區塊資料"
+// @Success      201       {array}   models.Section
+// @Failure      400,500   {object}  models.APIError
+// @Router       /plans/sections/bulk [post]
+func BulkCreateSections(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var inputs []models.CreateSectionInput
+		if error := context.ShouldBindJSON(&inputs); error != nil {
+			log.Printf("❌ Invalid input: %v", error)
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
+			return
+		}
+		if len(inputs) == 0 || len(inputs) > maxBulkSections {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, fmt.Sprintf("sections must contain 1 to %d items", maxBulkSections))
+			return
+		}
+		for index, input := range inputs {
+			if strings.TrimSpace(input.Title) == "" {
+				RespondError(context, http.StatusBadRequest, CodeInvalidInput, fmt.Sprintf("sections[%d].title is required", index))
+				return
+			}
+		}
+
+		userIdentifier := context.GetInt64("user_id")
+
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "DB transaction error")
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 鎖定使用者現有區塊，避免同時建立時 sort_order 重複
+		var maxSort sql.NullInt64
+		error = transaction.QueryRow("SELECT MAX(sort_order) FROM sections WHERE user_id = ? FOR UPDATE", userIdentifier).Scan(&maxSort)
+		if error != nil {
+			log.Printf("❌ Failed to query max sort: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to get max sort")
+			return
+		}
+
+		nextSort := 1
+		if maxSort.Valid {
+			nextSort = int(maxSort.Int64) + 1
+		}
+
+		identifiers := make([]int64, 0, len(inputs))
+		for _, input := range inputs {
+			result, error := transaction.Exec("INSERT INTO sections (user_id, title, sort_order) VALUES (?, ?, ?)", userIdentifier, input.Title, nextSort)
+			if error != nil {
+				log.Printf("❌ Failed to insert section: %v", error)
+				RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to create sections")
+				return
+			}
+			insertedIdentifier, _ := result.LastInsertId()
+			identifiers = append(identifiers, insertedIdentifier)
+			nextSort++
+		}
+
+		placeholders, args := buildInClause(identifiers)
+		rows, error := transaction.Query(`
+			SELECT id, title, sort_order, is_closed, archived, version, created_at, updated_at
+			FROM sections
+			WHERE id IN (`+placeholders+`)
+			ORDER BY sort_order ASC`, args...)
+		if error != nil {
+			log.Printf("❌ Failed to query created sections: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to create sections")
+			return
+		}
+		sections := make([]models.Section, 0, len(identifiers))
+		for rows.Next() {
+			var section models.Section
+			if error := rows.Scan(&section.ID, &section.Title, &section.SortOrder, &section.IsClosed, &section.IsArchived, &section.Version, &section.CreatedAt, &section.UpdatedAt); error != nil {
+				rows.Close()
+				log.Printf("❌ Failed to scan section: %v", error)
+				RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to create sections")
+				return
+			}
+			sections = append(sections, section)
+		}
+		rows.Close()
+
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Commit failed")
+			return
+		}
+
+		log.Printf("✅ Sections bulk created: Count=%d, UserID=%d", len(sections), userIdentifier)
+		context.JSON(http.StatusCreated, sections)
 	}
 }
 
 // GetSections godoc
 // @Summary      取得所有區塊（Section）
-// @Description  依照排序列出所有區塊
+// @Description  依照排序列出所有區塊，預設不含已封存的區塊；可用 fields 只回傳指定欄位
+// @ID           listSections
 // @Tags         Plans
 // @Produce      json
 // @Security     BearerAuth
+// @Param        fields  query  string  false  "只回傳的欄位，逗號分隔（例如 id,title）"
+// @Param        include_archived  query  bool  false  "包含已封存的區塊（預設不包含）"
+// @Param        from    query  string  false  "只包含建立時間不早於此時間的區塊（RFC 3339）"
+// @Param        to      query  string  false  "只包含建立時間不晚於此時間的區塊（RFC 3339），可只指定 from 或 to 其中一個"
 // @Success      200  {array}  models.Section
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
 // @Router       /plans/sections [get]
 func GetSections(database *sql.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		userIdentifier := context.GetInt64("user_id") // ✅ 直接取得 int64 型別的 user_id
 
-		rows, error := database.Query(`
-			SELECT id, title, sort_order, created_at, updated_at
+		fields, error := parseFields(context.Query("fields"), sectionFieldAllowlist)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, error.Error())
+			return
+		}
+		includeArchived, error := parseIncludeArchived(context)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, error.Error())
+			return
+		}
+		createdCondition, createdArgs, error := parseCreatedRange(context)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, error.Error())
+			return
+		}
+
+		args := append([]interface{}{userIdentifier}, createdArgs...)
+		rows, error := database.QueryContext(context.Request.Context(), `
+			SELECT id, title, sort_order, is_closed, archived, version, created_at, updated_at
 			FROM sections
-			WHERE user_id = ?
-			ORDER BY sort_order ASC`, userIdentifier)
+			WHERE user_id = ?`+archivedCondition(includeArchived)+createdCondition+`
+			ORDER BY sort_order ASC`, args...)
 		if error != nil {
 			log.Printf("❌ Failed to query sections: %v", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sections"})
+			respondServerError(context, "Failed to fetch sections")
 			return
 		}
 		defer rows.Close()
@@ -96,12 +259,258 @@ func GetSections(database *sql.DB) gin.HandlerFunc {
 		var sections []models.Section
 		for rows.Next() {
 			var section models.Section
-			if error := rows.Scan(&section.ID, &section.Title, &section.SortOrder, &section.CreatedAt, &section.UpdatedAt); error != nil {
+			if error := rows.Scan(&section.ID, &section.Title, &section.SortOrder, &section.IsClosed, &section.IsArchived, &section.Version, &section.CreatedAt, &section.UpdatedAt); error != nil {
+				log.Printf("❌ Failed to scan section: %v", error)
+				continue
+			}
+			sections = append(sections, section)
+		}
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to read sections: %v", error)
+			respondServerError(context, "Failed to fetch sections")
+			return
+		}
+
+		response, error := pickFields(sections, fields)
+		if error != nil {
+			log.Printf("❌ Failed to select fields: %v", error)
+			respondServerError(context, "Failed to fetch sections")
+			return
+		}
+		context.JSON(http.StatusOK, response)
+	}
+}
+
+// CountSections godoc
+// @Summary      取得區塊數量（HEAD）
+// @Description  只以 COUNT(*) 計算本人的區塊數（預設不含已封存的區塊），透過 X-Total-Count header 回傳，不含內容
+// @ID           countSections
+// @Tags         Plans
+// @Security     BearerAuth
+// @Param        include_archived  query  bool  false  "包含已封存的區塊（預設不包含）"
+// @Success      200  {string}  string  "X-Total-Count header"
+// @Header       200  {integer}  X-Total-Count  "區塊總數"
+// @Failure      400
+// @Failure      500
+// @Router       /plans/sections [head]
+func CountSections(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		includeArchived, error := parseIncludeArchived(context)
+		if error != nil {
+			context.Status(http.StatusBadRequest)
+			return
+		}
+
+		var total int
+		error = database.QueryRowContext(context.Request.Context(), "SELECT COUNT(*) FROM sections WHERE user_id = ?"+archivedCondition(includeArchived), userIdentifier).Scan(&total)
+		if error != nil {
+			log.Printf("❌ Failed to count sections: %v", error)
+			context.Status(serverErrorStatus(context))
+			return
+		}
+
+		context.Header("X-Total-Count", strconv.Itoa(total))
+		context.Status(http.StatusOK)
+	}
+}
+
+// GetSection godoc
+// @Summary      取得單一區塊（Section）
+// @Description  根據 ID 取得區塊，僅限本人的區塊，回傳格式與列表中的元素相同
+// @ID           getSection
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      200  {object}  models.Section
+// @Failure      400  {object}  models.APIError
+// @Failure      404  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id} [get]
+func GetSection(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid section ID")
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		// ✅ 只查詢該使用者的 section，不存在或不是本人的都回 404
+		var section models.Section
+		error = database.QueryRowContext(context.Request.Context(), `
+			SELECT id, title, sort_order, is_closed, archived, version, created_at, updated_at
+			FROM sections
+			WHERE id = ? AND user_id = ?`, identifier, userIdentifier).
+			Scan(&section.ID, &section.Title, &section.SortOrder, &section.IsClosed, &section.IsArchived, &section.Version, &section.CreatedAt, &section.UpdatedAt)
+		if error == sql.ErrNoRows {
+			RespondError(context, http.StatusNotFound, CodeSectionNotFound, "Section not found")
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to query section: %v", error)
+			respondServerError(context, "Failed to fetch section")
+			return
+		}
+
+		context.JSON(http.StatusOK, section)
+	}
+}
+
+// GetSectionsStats godoc
+// @Summary      取得所有區塊的完成統計
+// @Description  一次回傳使用者每個未封存區塊的任務總數、已完成數與完成百分比（依排序排列，沒有任務的區塊計為 0）
+// @ID           getSectionsStats
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.SectionStats
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/stats [get]
+func GetSectionsStats(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		stats, error := querySectionStats(context, database, userIdentifier)
+		if error != nil {
+			log.Printf("❌ Failed to query section stats: %v", error)
+			respondServerError(context, "Failed to fetch section stats")
+			return
+		}
+
+		context.JSON(http.StatusOK, stats)
+	}
+}
+
+// GetPlanStats godoc
+// @Summary      取得計畫的完成統計
+// @Description  回傳使用者每個未封存區塊的任務總數與已完成數（依排序排列，沒有任務的區塊計為 0），並附上所有區塊合計的統計
+// @ID           getPlanStats
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.PlanStats
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/stats [get]
+func GetPlanStats(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		stats, error := querySectionStats(context, database, userIdentifier)
+		if error != nil {
+			log.Printf("❌ Failed to query plan stats: %v", error)
+			respondServerError(context, "Failed to fetch plan stats")
+			return
+		}
+
+		plan := models.PlanStats{Sections: stats}
+		for _, stat := range stats {
+			plan.Total += stat.Total
+			plan.Completed += stat.Completed
+		}
+		plan.Percentage = completionPercentage(plan.Completed, plan.Total)
+
+		context.JSON(http.StatusOK, plan)
+	}
+}
+
+// querySectionStats 以單一 GROUP BY 查詢每個未封存區塊的任務數，LEFT JOIN 讓沒有任務的區塊也會出現
+func querySectionStats(context *gin.Context, database *sql.DB, userIdentifier int64) ([]models.SectionStats, error) {
+	rows, error := database.QueryContext(context.Request.Context(), `
+		SELECT s.id, s.title, COUNT(t.id), COALESCE(SUM(t.is_completed), 0)
+		FROM sections s
+		LEFT JOIN tasks t ON t.section_id = s.id AND t.deleted_at IS NULL
+		WHERE s.user_id = ? AND s.archived = FALSE
+		GROUP BY s.id, s.title, s.sort_order
+		ORDER BY s.sort_order ASC`, userIdentifier)
+	if error != nil {
+		return nil, error
+	}
+	defer rows.Close()
+
+	stats := []models.SectionStats{}
+	for rows.Next() {
+		var stat models.SectionStats
+		if error := rows.Scan(&stat.SectionID, &stat.Title, &stat.Total, &stat.Completed); error != nil {
+			return nil, error
+		}
+		stat.Percentage = completionPercentage(stat.Completed, stat.Total)
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// completionPercentage 回傳完成百分比（四捨五入到小數第二位），沒有任務時為 0
+func completionPercentage(completed int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(completed)/float64(total)*10000) / 100
+}
+
+const (
+	defaultRecentSectionsLimit = 10
+	maxRecentSectionsLimit     = 50
+)
+
+// GetRecentSections godoc
+// @Summary      取得最近活動的區塊
+// @Description  依區塊本身與其任務中最新的 updated_at 由新到舊排序，回傳使用者最近操作過的區塊（不含已封存的區塊）
+// @ID           listRecentSections
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        limit  query  int  false  "筆數（預設 10，最多 50）"
+// @Success      200  {array}   models.RecentSection
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/recent [get]
+func GetRecentSections(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		limit := defaultRecentSectionsLimit
+		if raw := context.Query("limit"); raw != "" {
+			value, error := strconv.Atoi(raw)
+			if error != nil || value < 1 || value > maxRecentSectionsLimit {
+				RespondError(context, http.StatusBadRequest, CodeInvalidInput, "limit must be between 1 and 50")
+				return
+			}
+			limit = value
+		}
+
+		rows, error := database.QueryContext(context.Request.Context(), `
+			SELECT s.id, s.title, s.sort_order, s.is_closed, s.archived, s.version, s.created_at, s.updated_at,
+				GREATEST(s.updated_at, COALESCE(MAX(t.updated_at), s.updated_at)) AS last_active_at
+			FROM sections s
+			LEFT JOIN tasks t ON t.section_id = s.id AND t.deleted_at IS NULL
+			WHERE s.user_id = ? AND s.archived = FALSE
+			GROUP BY s.id
+			ORDER BY last_active_at DESC, s.id DESC
+			LIMIT ?`, userIdentifier, limit)
+		if error != nil {
+			log.Printf("❌ Failed to query recent sections: %v", error)
+			respondServerError(context, "Failed to fetch sections")
+			return
+		}
+		defer rows.Close()
+
+		sections := []models.RecentSection{}
+		for rows.Next() {
+			var section models.RecentSection
+			if error := rows.Scan(&section.ID, &section.Title, &section.SortOrder, &section.IsClosed, &section.IsArchived, &section.Version, &section.CreatedAt, &section.UpdatedAt, &section.LastActiveAt); error != nil {
 				log.Printf("❌ Failed to scan section: %v", error)
 				continue
 			}
 			sections = append(sections, section)
 		}
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to read recent sections: %v", error)
+			respondServerError(context, "Failed to fetch sections")
+			return
+		}
 
 		context.JSON(http.StatusOK, sections)
 	}
@@ -109,13 +518,14 @@ func GetSections(database *sql.DB) gin.HandlerFunc {
 
 // DeleteSection godoc
 // @Summary      刪除區塊（Section）
-// @Description  根據 ID 刪除一個區塊，並重新排序該使用者的其他區塊
+// @Description  根據 ID 刪除一個區塊，並重新排序該使用者其他未封存的區塊（已封存的區塊保留原本的 sort_order）
+// @ID           deleteSection
 // @Tags         Plans
 // @Security     BearerAuth
 // @Param        id  path  int  true  "Section ID"
 // @Success      200  {object}  map[string]string
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
 // @Router       /plans/sections/{id} [delete]
 func DeleteSection(database *sql.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
@@ -131,7 +541,7 @@ func DeleteSection(database *sql.DB) gin.HandlerFunc {
 		`, identifier, userIdentifier).Scan(&exists)
 		if error != nil || !exists {
 			log.Printf("❌ Section %s not found or not owned by user %d", identifier, userIdentifier)
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Section not found or unauthorized"})
+			RespondError(context, http.StatusBadRequest, CodeSectionNotFound, "Section not found or unauthorized")
 			return
 		}
 
@@ -139,28 +549,25 @@ func DeleteSection(database *sql.DB) gin.HandlerFunc {
 		_, error = database.Exec("DELETE FROM sections WHERE id = ? AND user_id = ?", identifier, userIdentifier)
 		if error != nil {
 			log.Printf("❌ Failed to delete section %s: %v", identifier, error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete section"})
-			return
-		}
-
-		// 3️⃣ 重新初始化排序變數
-		_, error = database.Exec("SET @rank := 0")
-		if error != nil {
-			log.Printf("❌ Failed to reset rank variable")
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Section deleted, but failed to reorder"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to delete section")
 			return
 		}
 
-		// 4️⃣ 重排該使用者的 sections 排序
+		// 3️⃣ 以單一 SQL 重排該使用者未封存的 sections，已封存的保留原本的 sort_order；
+		// 不使用 @rank 等 session 變數，避免連線池把 SET 與 UPDATE 分配到不同連線
 		_, error = database.Exec(`
-			UPDATE sections
-			SET sort_order = (@rank := @rank + 1)
-			WHERE user_id = ?
-			ORDER BY sort_order ASC
+			UPDATE sections s
+			JOIN (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY sort_order ASC, id ASC) AS new_sort
+				FROM sections
+				WHERE user_id = ? AND archived = FALSE
+			) sorted
+			ON s.id = sorted.id
+			SET s.sort_order = sorted.new_sort
 		`, userIdentifier)
 		if error != nil {
 			log.Printf("❌ Failed to reorder sections for user %d: %v", userIdentifier, error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Section deleted, but failed to reorder"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Section deleted, but failed to reorder")
 			return
 		}
 
@@ -172,6 +579,7 @@ func DeleteSection(database *sql.DB) gin.HandlerFunc {
 // UpdateSection godoc
 // @Summary      更新區塊（Section 標題）
 // @Description  根據 ID 修改區塊的標題，僅限本人操作
+// @ID           updateSection
 // @Tags         Plans
 // @Accept       json
 // @Produce      json
@@ -179,8 +587,8 @@ func DeleteSection(database *sql.DB) gin.HandlerFunc {
 // @Param        id      path     int                       true  "Section ID"
 // @Param        section body     models.UpdateSectionInput true  "更新資料"
 // @Success      200     {object} map[string]interface{}
-// @Failure      400     {object} map[string]string
-// @Failure      500     {object} map[string]string
+// @Failure      400     {object} models.APIError
+// @Failure      500     {object} models.APIError
 // @Router       /plans/sections/{id} [put]
 func UpdateSection(database *sql.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
@@ -190,7 +598,7 @@ func UpdateSection(database *sql.DB) gin.HandlerFunc {
 		var input models.UpdateSectionInput
 		if error := context.ShouldBindJSON(&input); error != nil {
 			log.Printf("❌ Invalid input: %v", error)
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
 			return
 		}
 
@@ -199,7 +607,7 @@ func UpdateSection(database *sql.DB) gin.HandlerFunc {
 		error := database.QueryRow("SELECT EXISTS (SELECT 1 FROM sections WHERE id = ? AND user_id = ?)", identifier, userIdentifier).Scan(&exists)
 		if error != nil || !exists {
 			log.Printf("❌ Section %s not found or not owned by user %d", identifier, userIdentifier)
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Section not found or unauthorized"})
+			RespondError(context, http.StatusBadRequest, CodeSectionNotFound, "Section not found or unauthorized")
 			return
 		}
 
@@ -207,7 +615,7 @@ func UpdateSection(database *sql.DB) gin.HandlerFunc {
 		_, error = database.Exec("UPDATE sections SET title = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?", input.Title, identifier, userIdentifier)
 		if error != nil {
 			log.Printf("❌ Failed to update section title: %v", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update section"})
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to update section")
 			return
 		}
 
@@ -220,170 +628,823 @@ func UpdateSection(database *sql.DB) gin.HandlerFunc {
 	}
 }
 
-// GetSectionsWithTasks godoc
-// @Summary      取得所有區塊（含任務）
-// @Description  回傳每個區塊與其所屬任務（僅限本人），依照排序排列
+// SetSectionClosed godoc
+// @Summary      關閉／重新開啟區塊（Section）
+// @Description  關閉後的區塊不能再新增或移入任務，既有任務仍可編輯，僅限本人操作
+// @ID           setSectionClosed
 // @Tags         Plans
+// @Accept       json
+// @Produce      json
 // @Security     BearerAuth
-// @Success      200  {array}  models.SectionWithTasks
-// @Failure      500  {object}  map[string]string
-// @Router       /plans/sections-with-tasks [get]
-func GetSectionsWithTasks(database *sql.DB) gin.HandlerFunc {
+// @Param        id      path     int                          true  "Section ID"
+// @Param        body    body     models.SetSectionClosedInput true  "關閉狀態"
+// @Success      200     {object} map[string]interface{}
+// @Failure      400     {object} models.APIError
+// @Failure      500     {object} models.APIError
+// @Router       /plans/sections/{id}/closed [put]
+func SetSectionClosed(database *sql.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
+		identifier := context.Param("id")
 		userIdentifier := context.GetInt64("user_id")
 
-		// 1️⃣ 查詢所有屬於該 user 的 sections
-		sectionRows, error := database.Query(`
-			SELECT id, title, sort_order, created_at, updated_at
-			FROM sections
-			WHERE user_id = ?
-			ORDER BY sort_order ASC`, userIdentifier)
-		if error != nil {
-			log.Printf("❌ Failed to query sections: %v", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sections"})
+		var input models.SetSectionClosedInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			log.Printf("❌ Invalid input: %v", error)
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid input")
 			return
 		}
-		defer sectionRows.Close()
 
-		sectionsMap := make(map[int64]*models.SectionWithTasks)
-		var sectionIdentifiers []int64
-
-		for sectionRows.Next() {
-			var section models.SectionWithTasks
-			if error := sectionRows.Scan(&section.ID, &section.Title, &section.SortOrder, &section.CreatedAt, &section.UpdatedAt); error != nil {
-				log.Printf("❌ Failed to scan section: %v", error)
-				continue
-			}
-			section.Tasks = []models.Task{}
-			sectionsMap[section.ID] = &section
-			sectionIdentifiers = append(sectionIdentifiers, section.ID)
-		}
-
-		if len(sectionIdentifiers) == 0 {
-			context.JSON(http.StatusOK, []models.SectionWithTasks{})
+		// ✅ 確認該 section 是該使用者的
+		var exists bool
+		error := database.QueryRow("SELECT EXISTS (SELECT 1 FROM sections WHERE id = ? AND user_id = ?)", identifier, userIdentifier).Scan(&exists)
+		if error != nil || !exists {
+			log.Printf("❌ Section %s not found or not owned by user %d", identifier, userIdentifier)
+			RespondError(context, http.StatusBadRequest, CodeSectionNotFound, "Section not found or unauthorized")
 			return
 		}
 
-		// 2️⃣ 查詢所有對應的 tasks
-		query, args := buildTaskQuery(sectionIdentifiers)
-		taskRows, error := database.Query(query, args...)
+		_, error = database.Exec("UPDATE sections SET is_closed = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?", *input.IsClosed, identifier, userIdentifier)
 		if error != nil {
-			log.Printf("❌ Failed to query tasks: %v", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+			log.Printf("❌ Failed to update section closed state: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to update section")
 			return
 		}
-		defer taskRows.Close()
-
-		for taskRows.Next() {
-			var task models.Task
-			if error := taskRows.Scan(&task.ID, &task.SectionID, &task.Content, &task.IsCompleted, &task.SortOrder, &task.CreatedAt, &task.UpdatedAt, &task.Title); error != nil {
-				log.Printf("❌ Failed to scan task: %v", error)
-				continue
-			}
-			if section, isValid := sectionsMap[task.SectionID]; isValid {
-				section.Tasks = append(section.Tasks, task)
-			}
-		}
 
-		// 3️⃣ 整理成 slice
-		var result []models.SectionWithTasks
-		for _, identifier := range sectionIdentifiers {
-			result = append(result, *sectionsMap[identifier])
-		}
-
-		context.JSON(http.StatusOK, result)
+		log.Printf("✅ Section closed state updated: ID=%s, IsClosed=%t, UserID=%d", identifier, *input.IsClosed, userIdentifier)
+		context.JSON(http.StatusOK, gin.H{
+			"message":   "Section updated",
+			"id":        identifier,
+			"is_closed": *input.IsClosed,
+		})
 	}
 }
 
-func buildTaskQuery(sectionIdentifiers []int64) (string, []interface{}) {
-	query := `
-		SELECT id, section_id, content, is_completed, sort_order, created_at, updated_at, title
-		FROM tasks
-		WHERE section_id IN (?` + strings.Repeat(",?", len(sectionIdentifiers)-1) + `)
-		ORDER BY sort_order ASC`
-	args := make([]interface{}, len(sectionIdentifiers))
-	for index, identifier := range sectionIdentifiers {
-		args[index] = identifier
-	}
-	return query, args
+// ArchiveSection godoc
+// @Summary      封存區塊（Section）
+// @Description  將區塊從列表中隱藏但不刪除，區塊與任務都會保留，僅限本人操作
+// @ID           archiveSection
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id}/archive [put]
+func ArchiveSection(database *sql.DB) gin.HandlerFunc {
+	return setSectionArchived(database, true)
 }
 
-// UpdateSectionsWithTasks godoc
-// @Summary      批次更新區塊與任務排序
-// @Description  依據傳入資料更新 sections 與 tasks 的 sort_order（title/content 不會變動）
+// UnarchiveSection godoc
+// @Summary      取消封存區塊（Section）
+// @Description  讓已封存的區塊重新出現在列表中，保留封存前的 sort_order，僅限本人操作
+// @ID           unarchiveSection
 // @Tags         Plans
-// @Security     BearerAuth
-// @Accept       json
 // @Produce      json
-// @Param        body  body  []models.SectionWithTasks  true  "排序資料"
-// @Success      200   {object}  map[string]string
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
-// @Router       /plans/sections-with-tasks [put]
-func UpdateSectionsWithTasks(database *sql.DB) gin.HandlerFunc {
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id}/unarchive [put]
+func UnarchiveSection(database *sql.DB) gin.HandlerFunc {
+	return setSectionArchived(database, false)
+}
+
+func setSectionArchived(database *sql.DB, archived bool) gin.HandlerFunc {
 	return func(context *gin.Context) {
+		identifier := context.Param("id")
 		userIdentifier := context.GetInt64("user_id")
 
-		var sections []models.SectionWithTasks
-		if error := context.ShouldBindJSON(&sections); error != nil {
-			log.Printf("❌ Invalid input: %v", error)
-			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		// ✅ 確認該 section 是該使用者的
+		var exists bool
+		error := database.QueryRow("SELECT EXISTS (SELECT 1 FROM sections WHERE id = ? AND user_id = ?)", identifier, userIdentifier).Scan(&exists)
+		if error != nil || !exists {
+			log.Printf("❌ Section %s not found or not owned by user %d", identifier, userIdentifier)
+			RespondError(context, http.StatusBadRequest, CodeSectionNotFound, "Section not found or unauthorized")
 			return
 		}
 
-		transaction, error := database.Begin()
+		_, error = database.Exec("UPDATE sections SET archived = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?", archived, identifier, userIdentifier)
 		if error != nil {
-			log.Printf("❌ Failed to begin transaction: %v", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "DB transaction error"})
+			log.Printf("❌ Failed to update section archived state: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to update section")
 			return
 		}
 
-		for index, section := range sections {
-			// ✅ 檢查 section 是否屬於該使用者
-			var ownerIdentifier int64
-			error := transaction.QueryRow("SELECT user_id FROM sections WHERE id = ?", section.ID).Scan(&ownerIdentifier)
-			if error != nil || ownerIdentifier != userIdentifier {
-				transaction.Rollback()
-				log.Printf("❌ Unauthorized section update or not found: section_id=%d, user_id=%d", section.ID, userIdentifier)
-				context.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized section update"})
+		log.Printf("✅ Section archived state updated: ID=%s, IsArchived=%t, UserID=%d", identifier, archived, userIdentifier)
+		context.JSON(http.StatusOK, gin.H{
+			"message":     "Section updated",
+			"id":          identifier,
+			"is_archived": archived,
+		})
+	}
+}
+
+// CompleteAllTasks godoc
+// @Summary      將區塊內所有任務標記為完成
+// @Description  以單一 UPDATE 將區塊內所有未刪除、尚未完成的任務標記為完成並記錄 completed_at，回傳實際變更的任務數，僅限本人操作
+// @ID           completeAllTasks
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id}/complete-all [put]
+func CompleteAllTasks(database *sql.DB) gin.HandlerFunc {
+	return setSectionTasksCompleted(database, true)
+}
+
+// UncompleteAllTasks godoc
+// @Summary      將區塊內所有任務標記為未完成
+// @Description  以單一 UPDATE 將區塊內所有未刪除、已完成的任務改回未完成並清空 completed_at，回傳實際變更的任務數，僅限本人操作
+// @ID           uncompleteAllTasks
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id}/uncomplete-all [put]
+func UncompleteAllTasks(database *sql.DB) gin.HandlerFunc {
+	return setSectionTasksCompleted(database, false)
+}
+
+func setSectionTasksCompleted(database *sql.DB, completed bool) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier := context.Param("id")
+		userIdentifier := context.GetInt64("user_id")
+
+		// ✅ 確認該 section 是該使用者的
+		var exists bool
+		error := database.QueryRow("SELECT EXISTS (SELECT 1 FROM sections WHERE id = ? AND user_id = ?)", identifier, userIdentifier).Scan(&exists)
+		if error != nil || !exists {
+			log.Printf("❌ Section %s not found or not owned by user %d", identifier, userIdentifier)
+			RespondError(context, http.StatusBadRequest, CodeSectionNotFound, "Section not found or unauthorized")
+			return
+		}
+
+		// ✅ 只更新狀態不同的任務，回傳的筆數即為實際變更的任務數
+		result, error := database.Exec(`
+			UPDATE tasks
+			SET is_completed = ?,
+				completed_at = CASE WHEN ? THEN CURRENT_TIMESTAMP ELSE NULL END,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE section_id = ? AND user_id = ? AND deleted_at IS NULL AND is_completed <> ?`,
+			completed, completed, identifier, userIdentifier, completed)
+		if error != nil {
+			log.Printf("❌ Failed to update tasks completion: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to update tasks")
+			return
+		}
+		affected, _ := result.RowsAffected()
+
+		log.Printf("✅ Section tasks completion updated: ID=%s, IsCompleted=%t, Affected=%d, UserID=%d", identifier, completed, affected, userIdentifier)
+		context.JSON(http.StatusOK, gin.H{
+			"message":      "Tasks updated",
+			"id":           identifier,
+			"is_completed": completed,
+			"affected":     affected,
+		})
+	}
+}
+
+// ReorderSections godoc
+// @Summary      重新排序區塊
+// @Description  依陣列順序更新區塊的 sort_order，只排序區塊、不動任務。陣列必須剛好包含本人所有未封存的區塊且不可重複，否則整批不會寫入；已封存的區塊依原本順序排在最後
+// @ID           reorderSections
+// @Tags         Plans
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  models.ReorderSectionsInput  true  "新的區塊順序"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  models.APIError
+// @Failure      403   {object}  models.APIError
+// @Failure      500   {object}  models.APIError
+// @Router       /plans/sections/reorder [put]
+func ReorderSections(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		var input models.ReorderSectionsInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "section_ids must be a non-empty array of section IDs")
+			return
+		}
+		requested := make(map[int64]bool, len(input.SectionIDs))
+		for _, identifier := range input.SectionIDs {
+			if requested[identifier] {
+				RespondError(context, http.StatusBadRequest, CodeInvalidInput, fmt.Sprintf("Duplicate section ID %d", identifier))
 				return
 			}
+			requested[identifier] = true
+		}
 
-			// ✅ 更新 section 的排序
-			_, error = transaction.Exec("UPDATE sections SET sort_order = ? WHERE id = ?", index+1, section.ID)
+		error := db.WithTransaction(database, func(transaction *sql.Tx) error {
+			// ✅ 鎖定該使用者的 sections，避免並行排序互相覆蓋
+			rows, error := transaction.Query("SELECT id, archived FROM sections WHERE user_id = ? ORDER BY sort_order ASC, id ASC FOR UPDATE", userIdentifier)
 			if error != nil {
+				return error
+			}
+			owned := make(map[int64]bool)
+			var archivedIdentifiers []int64
+			for rows.Next() {
+				var identifier int64
+				var archived bool
+				if error := rows.Scan(&identifier, &archived); error != nil {
+					rows.Close()
+					return error
+				}
+				if archived {
+					archivedIdentifiers = append(archivedIdentifiers, identifier)
+					continue
+				}
+				owned[identifier] = true
+			}
+			rows.Close()
+			if error := rows.Err(); error != nil {
+				return error
+			}
+
+			for _, identifier := range input.SectionIDs {
+				if !owned[identifier] {
+					log.Printf("❌ Section %d not found, archived or not owned by user %d", identifier, userIdentifier)
+					return newBatchError(http.StatusForbidden, "Section %d not found or unauthorized", identifier).withCode(CodeSectionNotFound)
+				}
+			}
+			if len(input.SectionIDs) != len(owned) {
+				return newBatchError(http.StatusBadRequest, "section_ids must include all %d sections", len(owned))
+			}
+
+			ordered := append(append([]int64{}, input.SectionIDs...), archivedIdentifiers...)
+			for index, identifier := range ordered {
+				if _, error := transaction.Exec("UPDATE sections SET sort_order = ?, version = version + 1 WHERE id = ?", index+1, identifier); error != nil {
+					return error
+				}
+			}
+			return nil
+		})
+		if error != nil {
+			if batchErr, isValid := error.(*batchError); isValid {
+				RespondError(context, batchErr.status, batchErr.errorCode(), batchErr.message)
+				return
+			}
+			log.Printf("❌ Failed to reorder sections: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to reorder sections")
+			return
+		}
+
+		log.Printf("✅ Sections reordered: Count=%d, UserID=%d", len(input.SectionIDs), userIdentifier)
+		context.JSON(http.StatusOK, gin.H{
+			"message": "Sections reordered",
+			"order":   input.SectionIDs,
+		})
+	}
+}
+
+// MoveSectionAfter godoc
+// @Summary      將區塊移到另一個區塊之後
+// @Description  把區塊排到目標區塊的正後方並重新排序，兩個區塊都必須屬於本人
+// @ID           moveSectionAfter
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path  int  true  "要移動的 Section ID"
+// @Param        targetId  path  int  true  "目標 Section ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  models.APIError
+// @Failure      403  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id}/after/{targetId} [patch]
+func MoveSectionAfter(database *sql.DB) gin.HandlerFunc {
+	return moveSectionRelative(database, true)
+}
+
+// MoveSectionBefore godoc
+// @Summary      將區塊移到另一個區塊之前
+// @Description  把區塊排到目標區塊的正前方並重新排序，兩個區塊都必須屬於本人
+// @ID           moveSectionBefore
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path  int  true  "要移動的 Section ID"
+// @Param        targetId  path  int  true  "目標 Section ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  models.APIError
+// @Failure      403  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id}/before/{targetId} [patch]
+func MoveSectionBefore(database *sql.DB) gin.HandlerFunc {
+	return moveSectionRelative(database, false)
+}
+
+func moveSectionRelative(database *sql.DB, after bool) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid section ID")
+			return
+		}
+		targetIdentifier, error := strconv.ParseInt(context.Param("targetId"), 10, 64)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid target section ID")
+			return
+		}
+		if identifier == targetIdentifier {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Section cannot be moved relative to itself")
+			return
+		}
+
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "DB transaction error")
+			return
+		}
+
+		// ✅ 鎖定該使用者的 sections，避免並行排序互相覆蓋
+		identifiers, error := queryOrderedIdentifiers(transaction, "SELECT id FROM sections WHERE user_id = ? ORDER BY sort_order ASC, id ASC FOR UPDATE", userIdentifier)
+		if error != nil {
+			transaction.Rollback()
+			log.Printf("❌ Failed to query sections: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to fetch sections")
+			return
+		}
+
+		// ✅ 兩個 section 都必須屬於該使用者
+		movingIndex, targetIndex := -1, -1
+		for index, sectionIdentifier := range identifiers {
+			if sectionIdentifier == identifier {
+				movingIndex = index
+			}
+			if sectionIdentifier == targetIdentifier {
+				targetIndex = index
+			}
+		}
+		if movingIndex < 0 || targetIndex < 0 {
+			transaction.Rollback()
+			log.Printf("❌ Section %d or target %d not owned by user %d", identifier, targetIdentifier, userIdentifier)
+			RespondError(context, http.StatusForbidden, CodeSectionNotFound, "Section not found or unauthorized")
+			return
+		}
+
+		// 移除要移動的 section 後，目標位置（1 起算）
+		position := targetIndex + 1
+		if movingIndex < targetIndex {
+			position--
+		}
+		if after {
+			position++
+		}
+
+		ordered := placeIdentifier(identifiers, identifier, position)
+		for index, sectionIdentifier := range ordered {
+			if _, error := transaction.Exec("UPDATE sections SET sort_order = ?, version = version + 1 WHERE id = ?", index+1, sectionIdentifier); error != nil {
 				transaction.Rollback()
 				log.Printf("❌ Failed to update section sort_order: %v", error)
-				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update section sort"})
+				RespondError(context, http.StatusInternalServerError, CodeInternal, "Failed to update section sort")
 				return
 			}
+		}
 
-			// ✅ 處理每個 task
-			for taskIndex, task := range section.Tasks {
-				// ✅ 檢查 task 是否存在，並取得原 section_id
-				var originalSectionIdentifier int64
-				error := transaction.QueryRow("SELECT section_id FROM tasks WHERE id = ?", task.ID).Scan(&originalSectionIdentifier)
-				if error != nil {
-					transaction.Rollback()
-					log.Printf("❌ Task not found: task_id=%d", task.ID)
-					context.JSON(http.StatusBadRequest, gin.H{"error": "Task not found"})
-					return
-				}
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Failed to commit transaction: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Transaction commit failed")
+			return
+		}
 
-				// ✅ 無論是否跨 section，一律更新 section_id + sort_order
-				_, error = transaction.Exec("UPDATE tasks SET section_id = ?, sort_order = ? WHERE id = ?", section.ID, taskIndex+1, task.ID)
-				if error != nil {
-					transaction.Rollback()
-					log.Printf("❌ Failed to update task (id=%d) sort/section: %v", task.ID, error)
-					context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		log.Printf("✅ Section %d moved relative to %d (after=%t), UserID=%d", identifier, targetIdentifier, after, userIdentifier)
+		context.JSON(http.StatusOK, gin.H{
+			"message": "Section moved",
+			"order":   ordered,
+		})
+	}
+}
+
+// GetSectionsWithTasks godoc
+// @Summary      取得所有區塊（含任務）
+// @Description  回傳每個區塊與其所屬任務（僅限本人），依照排序排列，預設不含已封存的區塊；可依完成狀態、優先度、標籤與到期日篩選內嵌的任務
+// @ID           listSectionsWithTasks
+// @Tags         Plans
+// @Security     BearerAuth
+// @Param        include_archived  query  bool  false  "包含已封存的區塊（預設不包含）"
+// @Param        completed      query  bool  false  "只包含已完成（true）或未完成（false）的任務"
+// @Param        priority       query  string  false  "只包含指定優先度的任務"  Enums(low, medium, high)
+// @Param        sort           query  string  false  "區塊內任務的排序：sort_order（預設）或 priority（高到低，再依 sort_order）"  Enums(sort_order, priority)
+// @Param        tag            query  string  false  "只包含帶有此標籤的任務"
+// @Param        due_before     query  string  false  "只包含到期日早於此時間（RFC 3339）的任務"
+// @Param        only_nonempty  query  bool  false  "只回傳篩選後仍有任務的區塊"
+// @Param        include_deferred  query  bool  false  "包含仍在延後中的任務（預設不包含）"
+// @Success      200  {array}  models.SectionWithTasks
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections-with-tasks [get]
+func GetSectionsWithTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		filter, error := parseTaskTreeFilter(context)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, error.Error())
+			return
+		}
+
+		includeArchived, error := parseIncludeArchived(context)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, error.Error())
+			return
+		}
+
+		sections, error := loadSectionTree(context, database, userIdentifier, filter, includeArchived)
+		if error != nil {
+			log.Printf("❌ Failed to load sections with tasks: %v", error)
+			respondServerError(context, "Failed to fetch sections")
+			return
+		}
+
+		context.JSON(http.StatusOK, sections)
+	}
+}
+
+// loadSectionTree 查詢使用者的區塊並依 filter 內嵌任務，依區塊排序回傳；沒有區塊時回傳空 slice
+func loadSectionTree(context *gin.Context, database *sql.DB, userIdentifier int64, filter taskTreeFilter, includeArchived bool) ([]models.SectionWithTasks, error) {
+	// 1️⃣ 查詢所有屬於該 user 的 sections
+	sectionRows, error := database.QueryContext(context.Request.Context(), `
+		SELECT id, title, sort_order, is_closed, archived, version, created_at, updated_at
+		FROM sections
+		WHERE user_id = ?`+archivedCondition(includeArchived)+`
+		ORDER BY sort_order ASC`, userIdentifier)
+	if error != nil {
+		return nil, fmt.Errorf("query sections: %w", error)
+	}
+	defer sectionRows.Close()
+
+	sectionsMap := make(map[int64]*models.SectionWithTasks)
+	var sectionIdentifiers []int64
+
+	for sectionRows.Next() {
+		var section models.SectionWithTasks
+		if error := sectionRows.Scan(&section.ID, &section.Title, &section.SortOrder, &section.IsClosed, &section.IsArchived, &section.Version, &section.CreatedAt, &section.UpdatedAt); error != nil {
+			log.Printf("❌ Failed to scan section: %v", error)
+			continue
+		}
+		section.Tasks = []models.Task{}
+		sectionsMap[section.ID] = &section
+		sectionIdentifiers = append(sectionIdentifiers, section.ID)
+	}
+	// 逾時時 Next 會提早結束，必須檢查 Err 才不會回傳不完整的資料
+	if error := sectionRows.Err(); error != nil {
+		return nil, fmt.Errorf("read sections: %w", error)
+	}
+
+	if len(sectionIdentifiers) == 0 {
+		return []models.SectionWithTasks{}, nil
+	}
+
+	// 2️⃣ 查詢所有對應的 tasks
+	query, args := buildTaskQuery(sectionIdentifiers, filter)
+	taskRows, error := database.QueryContext(context.Request.Context(), query, args...)
+	if error != nil {
+		return nil, fmt.Errorf("query tasks: %w", error)
+	}
+	defer taskRows.Close()
+
+	for taskRows.Next() {
+		task, error := scanTask(taskRows)
+		if error != nil {
+			log.Printf("❌ Failed to scan task: %v", error)
+			continue
+		}
+		if section, isValid := sectionsMap[task.SectionID]; isValid {
+			section.Tasks = append(section.Tasks, task)
+		}
+	}
+	if error := taskRows.Err(); error != nil {
+		return nil, fmt.Errorf("read tasks: %w", error)
+	}
+
+	// 3️⃣ 整理成 slice
+	result := []models.SectionWithTasks{}
+	for _, identifier := range sectionIdentifiers {
+		if filter.OnlyNonEmpty && len(sectionsMap[identifier].Tasks) == 0 {
+			continue
+		}
+		result = append(result, *sectionsMap[identifier])
+	}
+	return result, nil
+}
+
+// taskColumns 與 scanTask 的欄位順序必須一致
+const taskColumns = "t.id, t.section_id, t.content, t.is_completed, t.priority, t.sort_order, t.version, t.created_at, t.updated_at, t.title, t.external_ref, t.due_date, t.deferred_until, t.deleted_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask 依 taskColumns 的順序讀取任務；查詢在 taskColumns 之後多選的欄位依序寫入 extra
+func scanTask(scanner rowScanner, extra ...interface{}) (models.Task, error) {
+	var task models.Task
+	destinations := []interface{}{&task.ID, &task.SectionID, &task.Content, &task.IsCompleted, &task.Priority, &task.SortOrder, &task.Version, &task.CreatedAt, &task.UpdatedAt, &task.Title, &task.ExternalRef, &task.DueDate, &task.DeferredUntil, &task.DeletedAt}
+	error := scanner.Scan(append(destinations, extra...)...)
+	return task, error
+}
+
+func buildTaskQuery(sectionIdentifiers []int64, filter taskTreeFilter) (string, []interface{}) {
+	placeholders, args := buildInClause(sectionIdentifiers)
+	orderBy := "t.sort_order ASC"
+	if filter.SortByPriority {
+		orderBy = "FIELD(t.priority, 'high', 'medium', 'low'), t.sort_order ASC"
+	}
+	conditions := "t.section_id IN (" + placeholders + ") AND t.deleted_at IS NULL"
+	if filter.Completed != nil {
+		conditions += " AND t.is_completed = ?"
+		args = append(args, *filter.Completed)
+	}
+	if filter.Priority != "" {
+		conditions += " AND t.priority = ?"
+		args = append(args, filter.Priority)
+	}
+	if filter.Label != "" {
+		conditions += " AND " + labelCondition
+		args = append(args, filter.Label)
+	}
+	if filter.DueBefore != nil {
+		conditions += " AND t.due_date IS NOT NULL AND t.due_date < ?"
+		args = append(args, *filter.DueBefore)
+	}
+	if !filter.IncludeDeferred {
+		condition, conditionArgs := notDeferredCondition()
+		conditions += " AND " + condition
+		args = append(args, conditionArgs...)
+	}
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks t
+		WHERE ` + conditions + `
+		ORDER BY ` + orderBy
+	return query, args
+}
+
+// taskTreeFilter 為 sections-with-tasks 內嵌任務的篩選條件
+type taskTreeFilter struct {
+	Completed       *bool
+	Priority        string
+	Label           string
+	DueBefore       *time.Time
+	SortByPriority  bool
+	OnlyNonEmpty    bool
+	IncludeDeferred bool
+}
+
+func parseTaskTreeFilter(context *gin.Context) (taskTreeFilter, error) {
+	var filter taskTreeFilter
+	filter.Label = strings.TrimSpace(context.Query("tag"))
+
+	if raw := context.Query("completed"); raw != "" {
+		completed, error := strconv.ParseBool(raw)
+		if error != nil {
+			return filter, fmt.Errorf("completed must be true or false")
+		}
+		filter.Completed = &completed
+	}
+	if raw := context.Query("priority"); raw != "" {
+		if !isValidTaskPriority(raw) {
+			return filter, fmt.Errorf("priority must be low, medium or high")
+		}
+		filter.Priority = raw
+	}
+	switch context.Query("sort") {
+	case "", "sort_order":
+	case "priority":
+		filter.SortByPriority = true
+	default:
+		return filter, fmt.Errorf("sort must be sort_order or priority")
+	}
+	if raw := context.Query("due_before"); raw != "" {
+		dueBefore, error := time.Parse(time.RFC3339, raw)
+		if error != nil {
+			return filter, fmt.Errorf("due_before must be an RFC 3339 time")
+		}
+		dueBefore = dueBefore.UTC()
+		filter.DueBefore = &dueBefore
+	}
+	if raw := context.Query("only_nonempty"); raw != "" {
+		onlyNonEmpty, error := strconv.ParseBool(raw)
+		if error != nil {
+			return filter, fmt.Errorf("only_nonempty must be true or false")
+		}
+		filter.OnlyNonEmpty = onlyNonEmpty
+	}
+	includeDeferred, error := parseIncludeDeferred(context)
+	if error != nil {
+		return filter, error
+	}
+	filter.IncludeDeferred = includeDeferred
+	return filter, nil
+}
+
+// parseIncludeDeferred 解析 include_deferred，預設不包含仍在延後中的任務
+func parseIncludeDeferred(context *gin.Context) (bool, error) {
+	raw := context.Query("include_deferred")
+	if raw == "" {
+		return false, nil
+	}
+	includeDeferred, error := strconv.ParseBool(raw)
+	if error != nil {
+		return false, fmt.Errorf("include_deferred must be true or false")
+	}
+	return includeDeferred, nil
+}
+
+// parseIncludeArchived 解析 include_archived，預設不包含已封存的區塊
+func parseIncludeArchived(context *gin.Context) (bool, error) {
+	raw := context.Query("include_archived")
+	if raw == "" {
+		return false, nil
+	}
+	includeArchived, error := strconv.ParseBool(raw)
+	if error != nil {
+		return false, fmt.Errorf("include_archived must be true or false")
+	}
+	return includeArchived, nil
+}
+
+// parseCreatedRange 解析 from / to（RFC 3339），回傳附加的 created_at 條件；只指定一邊時為開放區間
+func parseCreatedRange(context *gin.Context) (string, []interface{}, error) {
+	from, error := parseTimeQuery(context, "from")
+	if error != nil {
+		return "", nil, error
+	}
+	to, error := parseTimeQuery(context, "to")
+	if error != nil {
+		return "", nil, error
+	}
+
+	switch {
+	case from != nil && to != nil:
+		if from.After(*to) {
+			return "", nil, fmt.Errorf("from must not be after to")
+		}
+		return " AND created_at BETWEEN ? AND ?", []interface{}{*from, *to}, nil
+	case from != nil:
+		return " AND created_at >= ?", []interface{}{*from}, nil
+	case to != nil:
+		return " AND created_at <= ?", []interface{}{*to}, nil
+	}
+	return "", nil, nil
+}
+
+// parseTimeQuery 解析 RFC 3339 的 query 參數並轉為 UTC，未提供時回傳 nil
+func parseTimeQuery(context *gin.Context, name string) (*time.Time, error) {
+	raw := context.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	parsed, error := time.Parse(time.RFC3339, raw)
+	if error != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	parsed = parsed.UTC()
+	return &parsed, nil
+}
+
+// archivedCondition 回傳排除已封存區塊的附加 sections 條件
+func archivedCondition(includeArchived bool) string {
+	if includeArchived {
+		return ""
+	}
+	return " AND archived = FALSE"
+}
+
+// notDeferredCondition 排除 deferred_until 還在未來的任務（時間一律以 UTC 比較）
+func notDeferredCondition() (string, []interface{}) {
+	return "(t.deferred_until IS NULL OR t.deferred_until <= ?)", []interface{}{time.Now().UTC()}
+}
+
+// buildInClause 產生 IN (...) 用的佔位符與參數；空清單回傳 NULL，使條件不匹配任何資料而非產生錯誤的 SQL
+func buildInClause(identifiers []int64) (string, []interface{}) {
+	if len(identifiers) == 0 {
+		return "NULL", nil
+	}
+	args := make([]interface{}, len(identifiers))
+	for index, identifier := range identifiers {
+		args[index] = identifier
+	}
+	return "?" + strings.Repeat(",?", len(identifiers)-1), args
+}
+
+// errPlanModified 為批次排序時偵測到版本不符的錯誤訊息
+const errPlanModified = "Plan was modified, please refresh"
+
+// UpdateSectionsWithTasks godoc
+// @Summary      批次更新區塊與任務排序
+// @Description  依據傳入資料更新 sections 與 tasks 的 sort_order（title/content 不會變動）。
+// @Description  每個 section 與 task 都必須帶上最後讀到的 version；任一筆版本不符代表資料已被其他分頁或裝置修改，整批不會寫入並回傳 409，前端應重新讀取後再送出
+// @ID           updateSectionsWithTasks
+// @Tags         Plans
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body  body  []models.SectionWithTasks  true  "排序資料"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  models.APIError
+// @Failure      403   {object}  models.APIError
+// @Failure      409   {object}  models.APIError
+// @Failure      500   {object}  models.APIError
+// @Router       /plans/sections-with-tasks [put]
+func UpdateSectionsWithTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		var sections []models.SectionWithTasks
+		if error := context.ShouldBindJSON(&sections); error != nil {
+			log.Printf("❌ Invalid input: %v", error)
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid request format")
+			return
+		}
+
+		for _, section := range sections {
+			if section.Version < 1 {
+				RespondError(context, http.StatusBadRequest, CodeInvalidInput, "version is required for every section")
+				return
+			}
+			for _, task := range section.Tasks {
+				if task.Version < 1 {
+					RespondError(context, http.StatusBadRequest, CodeInvalidInput, "version is required for every task")
 					return
 				}
 			}
 		}
 
-		if error := transaction.Commit(); error != nil {
-			log.Printf("❌ Failed to commit transaction: %v", error)
-			context.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction commit failed"})
+		error := db.WithTransaction(database, func(transaction *sql.Tx) error {
+			for index, section := range sections {
+				// ✅ 檢查 section 是否屬於該使用者
+				var ownerIdentifier int64
+				var isClosed bool
+				error := transaction.QueryRow("SELECT user_id, is_closed FROM sections WHERE id = ?", section.ID).Scan(&ownerIdentifier, &isClosed)
+				if error != nil || ownerIdentifier != userIdentifier {
+					log.Printf("❌ Unauthorized section update or not found: section_id=%d, user_id=%d", section.ID, userIdentifier)
+					return newBatchError(http.StatusForbidden, "Unauthorized section update").withCode(CodeSectionNotFound)
+				}
+
+				// ✅ 更新 section 的排序，版本不符代表已被其他請求修改
+				result, error := transaction.Exec("UPDATE sections SET sort_order = ?, version = version + 1 WHERE id = ? AND version = ?", index+1, section.ID, section.Version)
+				if error != nil {
+					log.Printf("❌ Failed to update section sort_order: %v", error)
+					return newBatchError(http.StatusInternalServerError, "Failed to update section sort")
+				}
+				if affected, _ := result.RowsAffected(); affected == 0 {
+					log.Printf("❌ Stale section version: section_id=%d, version=%d", section.ID, section.Version)
+					return newBatchError(http.StatusConflict, errPlanModified).withCode(CodePlanModified)
+				}
+
+				// ✅ 處理每個 task
+				for taskIndex, task := range section.Tasks {
+					// ✅ 檢查 task 是否存在且屬於該使用者，並取得原 section_id
+					var originalSectionIdentifier int64
+					error := transaction.QueryRow("SELECT section_id FROM tasks WHERE id = ? AND user_id = ? AND deleted_at IS NULL", task.ID, userIdentifier).Scan(&originalSectionIdentifier)
+					if error == sql.ErrNoRows {
+						log.Printf("❌ Unauthorized task update or not found: task_id=%d, user_id=%d", task.ID, userIdentifier)
+						return newBatchError(http.StatusForbidden, "Unauthorized task update").withCode(CodeTaskNotFound)
+					}
+					if error != nil {
+						log.Printf("❌ Failed to query task %d: %v", task.ID, error)
+						return newBatchError(http.StatusInternalServerError, "Failed to update task")
+					}
+
+					// ✅ 已關閉的 section 不能再移入新任務
+					if isClosed && originalSectionIdentifier != section.ID {
+						log.Printf("❌ Cannot move task %d into closed section %d", task.ID, section.ID)
+						return newBatchError(http.StatusConflict, "Section is closed").withCode(CodeSectionClosed)
+					}
+
+					// ✅ 無論是否跨 section，一律更新 section_id + sort_order
+					result, error := transaction.Exec("UPDATE tasks SET section_id = ?, sort_order = ?, version = version + 1 WHERE id = ? AND version = ?", section.ID, taskIndex+1, task.ID, task.Version)
+					if error != nil {
+						log.Printf("❌ Failed to update task (id=%d) sort/section: %v", task.ID, error)
+						return newBatchError(http.StatusInternalServerError, "Failed to update task")
+					}
+					if affected, _ := result.RowsAffected(); affected == 0 {
+						log.Printf("❌ Stale task version: task_id=%d, version=%d", task.ID, task.Version)
+						return newBatchError(http.StatusConflict, errPlanModified).withCode(CodePlanModified)
+					}
+				}
+			}
+			return nil
+		})
+		if error != nil {
+			if batchErr, isValid := error.(*batchError); isValid {
+				RespondError(context, batchErr.status, batchErr.errorCode(), batchErr.message)
+				return
+			}
+			log.Printf("❌ Failed to update sort orders: %v", error)
+			RespondError(context, http.StatusInternalServerError, CodeInternal, "Transaction commit failed")
 			return
 		}
 
@@ -391,3 +1452,186 @@ func UpdateSectionsWithTasks(database *sql.DB) gin.HandlerFunc {
 		context.JSON(http.StatusOK, gin.H{"message": "Sort orders updated"})
 	}
 }
+
+const (
+	defaultForecastWindowDays = 14
+	maxForecastWindowDays     = 90
+)
+
+// GetSectionForecast godoc
+// @Summary      預估區塊完成日期
+// @Description  依照使用者近期的任務完成速度（每日完成數）與區塊內剩餘未完成任務數，預估區塊的完成日期
+// @ID           getSectionForecast
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id           path   int  true   "Section ID"
+// @Param        window_days  query  int  false  "計算速度的回溯天數（預設 14，最多 90）"
+// @Success      200  {object}  models.SectionForecast
+// @Failure      400  {object}  models.APIError
+// @Failure      404  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id}/forecast [get]
+func GetSectionForecast(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid section ID")
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		windowDays := defaultForecastWindowDays
+		if raw := context.Query("window_days"); raw != "" {
+			windowDays, error = strconv.Atoi(raw)
+			if error != nil || windowDays < 1 || windowDays > maxForecastWindowDays {
+				RespondError(context, http.StatusBadRequest, CodeInvalidInput, "window_days must be between 1 and 90")
+				return
+			}
+		}
+
+		// ✅ 確認該 section 是該使用者的
+		var exists bool
+		error = database.QueryRowContext(context.Request.Context(), "SELECT EXISTS (SELECT 1 FROM sections WHERE id = ? AND user_id = ?)", identifier, userIdentifier).Scan(&exists)
+		if error != nil {
+			log.Printf("❌ Failed to check section ownership: %v", error)
+			respondServerError(context, "Failed to fetch section")
+			return
+		}
+		if !exists {
+			RespondError(context, http.StatusNotFound, CodeSectionNotFound, "Section not found")
+			return
+		}
+
+		// ✅ 區塊內剩餘的未完成任務
+		var remaining int
+		error = database.QueryRowContext(context.Request.Context(), "SELECT COUNT(*) FROM tasks WHERE section_id = ? AND is_completed = FALSE AND deleted_at IS NULL", identifier).Scan(&remaining)
+		if error != nil {
+			log.Printf("❌ Failed to count remaining tasks: %v", error)
+			respondServerError(context, "Failed to compute forecast")
+			return
+		}
+
+		// ✅ 使用者在回溯期間內完成的任務數
+		var completed int
+		error = database.QueryRowContext(context.Request.Context(), `
+			SELECT COUNT(*)
+			FROM tasks
+			WHERE user_id = ? AND completed_at IS NOT NULL
+			  AND completed_at >= NOW() - INTERVAL ? DAY`, userIdentifier, windowDays).Scan(&completed)
+		if error != nil {
+			log.Printf("❌ Failed to compute velocity: %v", error)
+			respondServerError(context, "Failed to compute forecast")
+			return
+		}
+
+		forecast := models.SectionForecast{
+			SectionID:         identifier,
+			RemainingTasks:    remaining,
+			WindowDays:        windowDays,
+			CompletedInWindow: completed,
+			VelocityPerDay:    float64(completed) / float64(windowDays),
+		}
+
+		// 近期沒有完成任何任務時無法推估，預估欄位保持為 null
+		today := time.Now()
+		switch {
+		case remaining == 0:
+			days := 0
+			date := today.Format("2006-01-02")
+			forecast.EstimatedDays = &days
+			forecast.EstimatedCompletionDate = &date
+		case forecast.VelocityPerDay > 0:
+			days := int(math.Ceil(float64(remaining) / forecast.VelocityPerDay))
+			date := today.AddDate(0, 0, days).Format("2006-01-02")
+			forecast.EstimatedDays = &days
+			forecast.EstimatedCompletionDate = &date
+		}
+
+		context.JSON(http.StatusOK, forecast)
+	}
+}
+
+// GetSectionPriorityBreakdown godoc
+// @Summary      取得區塊任務的優先度分布
+// @Description  以單一 GROUP BY 查詢統計區塊內未刪除任務在各優先度（low / medium / high）的數量，以及已完成與未完成的數量；沒有任務的優先度回傳 0
+// @ID           getSectionPriorityBreakdown
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  int  true  "Section ID"
+// @Success      200  {object}  models.SectionPriorityBreakdown
+// @Failure      400  {object}  models.APIError
+// @Failure      404  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/sections/{id}/priority-breakdown [get]
+func GetSectionPriorityBreakdown(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "Invalid section ID")
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		// ✅ 確認該 section 是該使用者的
+		var exists bool
+		error = database.QueryRowContext(context.Request.Context(), "SELECT EXISTS (SELECT 1 FROM sections WHERE id = ? AND user_id = ?)", identifier, userIdentifier).Scan(&exists)
+		if error != nil {
+			log.Printf("❌ Failed to check section ownership: %v", error)
+			respondServerError(context, "Failed to fetch section")
+			return
+		}
+		if !exists {
+			RespondError(context, http.StatusNotFound, CodeSectionNotFound, "Section not found")
+			return
+		}
+
+		// ✅ 一次依優先度與完成狀態分組，兩種分布都由同一份結果加總
+		rows, error := database.QueryContext(context.Request.Context(), `
+			SELECT priority, is_completed, COUNT(*)
+			FROM tasks
+			WHERE section_id = ? AND deleted_at IS NULL
+			GROUP BY priority, is_completed`, identifier)
+		if error != nil {
+			log.Printf("❌ Failed to query priority breakdown: %v", error)
+			respondServerError(context, "Failed to fetch priority breakdown")
+			return
+		}
+		defer rows.Close()
+
+		breakdown := models.SectionPriorityBreakdown{SectionID: identifier}
+		for rows.Next() {
+			var priority string
+			var isCompleted bool
+			var count int
+			if error := rows.Scan(&priority, &isCompleted, &count); error != nil {
+				log.Printf("❌ Failed to scan priority breakdown: %v", error)
+				respondServerError(context, "Failed to fetch priority breakdown")
+				return
+			}
+
+			switch priority {
+			case "low":
+				breakdown.ByPriority.Low += count
+			case "high":
+				breakdown.ByPriority.High += count
+			default:
+				breakdown.ByPriority.Medium += count
+			}
+			if isCompleted {
+				breakdown.ByCompletion.Completed += count
+			} else {
+				breakdown.ByCompletion.Incomplete += count
+			}
+			breakdown.Total += count
+		}
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to read priority breakdown: %v", error)
+			respondServerError(context, "Failed to fetch priority breakdown")
+			return
+		}
+
+		context.JSON(http.StatusOK, breakdown)
+	}
+}
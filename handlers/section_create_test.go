@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSectionStore 是只支援 CreateSection 查詢的記憶體資料庫，用來模擬 MySQL 的 SELECT ... FOR UPDATE：
+// 取得鎖的 transaction 在 commit 或 rollback 之前，其他 transaction 的 FOR UPDATE 查詢會被擋住
+type fakeSectionStore struct {
+	rowLock  sync.Mutex
+	mutex    sync.Mutex
+	sections []models.Section
+}
+
+func (store *fakeSectionStore) Connect(context.Context) (driver.Conn, error) {
+	return &fakeSectionConn{store: store}, nil
+}
+
+func (store *fakeSectionStore) Driver() driver.Driver { return nil }
+
+type fakeSectionConn struct {
+	store     *fakeSectionStore
+	holdsLock bool
+}
+
+func (conn *fakeSectionConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSectionStmt{conn: conn, query: query}, nil
+}
+
+func (conn *fakeSectionConn) Close() error { return nil }
+
+func (conn *fakeSectionConn) Begin() (driver.Tx, error) { return conn, nil }
+
+func (conn *fakeSectionConn) Commit() error   { conn.release(); return nil }
+func (conn *fakeSectionConn) Rollback() error { conn.release(); return nil }
+
+func (conn *fakeSectionConn) release() {
+	if conn.holdsLock {
+		conn.holdsLock = false
+		conn.store.rowLock.Unlock()
+	}
+}
+
+type fakeSectionStmt struct {
+	conn  *fakeSectionConn
+	query string
+}
+
+func (stmt *fakeSectionStmt) Close() error  { return nil }
+func (stmt *fakeSectionStmt) NumInput() int { return -1 }
+
+func (stmt *fakeSectionStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(stmt.query, "INSERT INTO sections") {
+		return nil, fmt.Errorf("unexpected exec: %s", stmt.query)
+	}
+	store := stmt.conn.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	now := time.Now()
+	section := models.Section{
+		ID:        int64(len(store.sections) + 1),
+		Title:     args[1].(string),
+		SortOrder: int(args[2].(int64)),
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	store.sections = append(store.sections, section)
+	return fakeResult(section.ID), nil
+}
+
+// fakeResult 為 INSERT 的結果，值即新資料列的 ID
+type fakeResult int64
+
+func (result fakeResult) LastInsertId() (int64, error) { return int64(result), nil }
+func (result fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (stmt *fakeSectionStmt) Query(args []driver.Value) (driver.Rows, error) {
+	store := stmt.conn.store
+	switch {
+	case strings.Contains(stmt.query, "SELECT MAX(sort_order) FROM sections"):
+		if strings.Contains(stmt.query, "FOR UPDATE") && !stmt.conn.holdsLock {
+			store.rowLock.Lock()
+			stmt.conn.holdsLock = true
+		}
+		store.mutex.Lock()
+		var maxSort driver.Value
+		for _, section := range store.sections {
+			if maxSort == nil || int64(section.SortOrder) > maxSort.(int64) {
+				maxSort = int64(section.SortOrder)
+			}
+		}
+		store.mutex.Unlock()
+		// 拉長讀取與寫入之間的間隔，沒有鎖時並行的請求會讀到相同的最大值
+		time.Sleep(5 * time.Millisecond)
+		return &fakeRows{columns: []string{"max"}, values: [][]driver.Value{{maxSort}}}, nil
+	case strings.Contains(stmt.query, "FROM sections") && strings.Contains(stmt.query, "WHERE id = ?"):
+		store.mutex.Lock()
+		defer store.mutex.Unlock()
+		for _, section := range store.sections {
+			if section.ID == args[0].(int64) {
+				return &fakeRows{
+					columns: []string{"id", "title", "sort_order", "is_closed", "archived", "version", "created_at", "updated_at"},
+					values: [][]driver.Value{{section.ID, section.Title, int64(section.SortOrder), section.IsClosed, section.IsArchived,
+						int64(section.Version), section.CreatedAt, section.UpdatedAt}},
+				}, nil
+			}
+		}
+		return &fakeRows{columns: []string{"id"}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", stmt.query)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (rows *fakeRows) Columns() []string { return rows.columns }
+func (rows *fakeRows) Close() error      { return nil }
+
+func (rows *fakeRows) Next(dest []driver.Value) error {
+	if len(rows.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, rows.values[0])
+	rows.values = rows.values[1:]
+	return nil
+}
+
+func TestCreateSectionConcurrentRequestsGetDistinctSortOrders(t *testing.T) {
+	store := &fakeSectionStore{}
+	database := sql.OpenDB(store)
+	defer database.Close()
+
+	router := gin.New()
+	router.POST("/plans/sections", withUser(1), CreateSection(database))
+
+	const requests = 10
+	var waitGroup sync.WaitGroup
+	failures := make(chan error, requests)
+	for index := 0; index < requests; index++ {
+		waitGroup.Add(1)
+		go func(index int) {
+			defer waitGroup.Done()
+			recorder := performRequest(router, http.MethodPost, "/plans/sections", fmt.Sprintf(`{"title":"Section %d"}`, index))
+			if recorder.Code != http.StatusCreated {
+				failures <- fmt.Errorf("expected 201, got %d: %s", recorder.Code, recorder.Body.String())
+				return
+			}
+			var section models.Section
+			if err := json.Unmarshal(recorder.Body.Bytes(), &section); err != nil {
+				failures <- err
+			}
+		}(index)
+	}
+	waitGroup.Wait()
+	close(failures)
+	for err := range failures {
+		t.Error(err)
+	}
+
+	seen := map[int]bool{}
+	for _, section := range store.sections {
+		if seen[section.SortOrder] {
+			t.Fatalf("duplicate sort_order %d among %+v", section.SortOrder, store.sections)
+		}
+		seen[section.SortOrder] = true
+	}
+	if len(seen) != requests {
+		t.Fatalf("expected %d sections, got %d", requests, len(seen))
+	}
+	for sortOrder := 1; sortOrder <= requests; sortOrder++ {
+		if !seen[sortOrder] {
+			t.Fatalf("missing sort_order %d", sortOrder)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSectionTitleLength 對應 sections.title 的 VARCHAR(255)
+const maxSectionTitleLength = 255
+
+// duplicateTitleSuffix 為複製區塊時附加在標題後的字樣
+const duplicateTitleSuffix = " (copy)"
+
+// DuplicateSection godoc
+// @Summary      複製區塊（Section）
+// @Description  以既有區塊為範本建立新區塊，標題加上「(copy)」並排在使用者所有區塊最後，所有未刪除的任務會一併複製（標題、內容、優先度），完成狀態重設為未完成。整個複製在同一個 transaction 中完成
+// @ID           duplicateSection
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/sections/{id}/duplicate [post]
+func DuplicateSection(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "DB transaction error"})
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 確認該 section 是該使用者的
+		var title string
+		error = transaction.QueryRow("SELECT title FROM sections WHERE id = ? AND user_id = ?", identifier, userIdentifier).Scan(&title)
+		if error == sql.ErrNoRows {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Section not found"})
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to check section ownership: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate section"})
+			return
+		}
+
+		// ✅ 鎖定使用者現有區塊，避免同時建立時 sort_order 重複
+		var maxSort sql.NullInt64
+		error = transaction.QueryRow("SELECT MAX(sort_order) FROM sections WHERE user_id = ? FOR UPDATE", userIdentifier).Scan(&maxSort)
+		if error != nil {
+			log.Printf("❌ Failed to query max sort: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get max sort"})
+			return
+		}
+		newSort := 1
+		if maxSort.Valid {
+			newSort = int(maxSort.Int64) + 1
+		}
+
+		result, error := transaction.Exec("INSERT INTO sections (user_id, title, sort_order) VALUES (?, ?, ?)", userIdentifier, duplicateTitle(title), newSort)
+		if error != nil {
+			log.Printf("❌ Failed to insert section: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate section"})
+			return
+		}
+		duplicateIdentifier, _ := result.LastInsertId()
+
+		type taskTemplate struct {
+			Title    string
+			Content  string
+			Priority string
+		}
+		rows, error := transaction.Query(`
+			SELECT title, content, priority
+			FROM tasks
+			WHERE section_id = ? AND user_id = ? AND deleted_at IS NULL
+			ORDER BY sort_order ASC, id ASC`, identifier, userIdentifier)
+		if error != nil {
+			log.Printf("❌ Failed to load tasks to duplicate: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate section"})
+			return
+		}
+		templates := []taskTemplate{}
+		for rows.Next() {
+			var template taskTemplate
+			if error := rows.Scan(&template.Title, &template.Content, &template.Priority); error != nil {
+				rows.Close()
+				log.Printf("❌ Failed to scan task to duplicate: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate section"})
+				return
+			}
+			templates = append(templates, template)
+		}
+		rows.Close()
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to load tasks to duplicate: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate section"})
+			return
+		}
+
+		for index, template := range templates {
+			_, error := transaction.Exec(
+				"INSERT INTO tasks (user_id, section_id, title, content, is_completed, priority, sort_order) VALUES (?, ?, ?, ?, FALSE, ?, ?)",
+				userIdentifier, duplicateIdentifier, template.Title, template.Content, taskPriority(template.Priority), index+1,
+			)
+			if error != nil {
+				log.Printf("❌ Failed to duplicate task: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate section"})
+				return
+			}
+		}
+
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+			return
+		}
+
+		log.Printf("✅ Section duplicated: SourceID=%d, ID=%d, Tasks=%d, UserID=%d", identifier, duplicateIdentifier, len(templates), userIdentifier)
+		context.JSON(http.StatusCreated, gin.H{
+			"id":         duplicateIdentifier,
+			"source_id":  identifier,
+			"task_count": len(templates),
+		})
+	}
+}
+
+// duplicateTitle 在原標題後加上「(copy)」，過長時截斷原標題以符合欄位長度
+func duplicateTitle(title string) string {
+	runes := []rune(title)
+	limit := maxSectionTitleLength - len([]rune(duplicateTitleSuffix))
+	if len(runes) > limit {
+		runes = runes[:limit]
+	}
+	return string(runes) + duplicateTitleSuffix
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSectionSnapshot godoc
+// @Summary      建立區塊快照
+// @Description  保存區塊目前所有任務（含排序、完成狀態與標籤），之後可用來還原。每個區塊最多保留 20 份，超過保留期限（30 天）的快照會一併清除
+// @ID           createSectionSnapshot
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      201  {object}  models.SectionSnapshot
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/sections/{id}/snapshots [post]
+func CreateSectionSnapshot(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "DB transaction error"})
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 確認該 section 是該使用者的
+		var exists bool
+		error = transaction.QueryRow("SELECT EXISTS (SELECT 1 FROM sections WHERE id = ? AND user_id = ?)", identifier, userIdentifier).Scan(&exists)
+		if error != nil {
+			log.Printf("❌ Failed to check section ownership: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+			return
+		}
+		if !exists {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Section not found"})
+			return
+		}
+
+		rows, error := transaction.Query(`
+			SELECT id, title, content, is_completed, priority, completed_at, sort_order, external_ref, due_date, deferred_until
+			FROM tasks
+			WHERE section_id = ? AND user_id = ? AND deleted_at IS NULL
+			ORDER BY sort_order ASC, id ASC`, identifier, userIdentifier)
+		if error != nil {
+			log.Printf("❌ Failed to load tasks for snapshot: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+			return
+		}
+		snapshotTasks := []models.SnapshotTask{}
+		taskIndexes := make(map[int64]int)
+		for rows.Next() {
+			var taskIdentifier int64
+			var task models.SnapshotTask
+			if error := rows.Scan(&taskIdentifier, &task.Title, &task.Content, &task.IsCompleted, &task.Priority, &task.CompletedAt, &task.SortOrder, &task.ExternalRef, &task.DueDate, &task.DeferredUntil); error != nil {
+				rows.Close()
+				log.Printf("❌ Failed to scan task for snapshot: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+				return
+			}
+			taskIndexes[taskIdentifier] = len(snapshotTasks)
+			snapshotTasks = append(snapshotTasks, task)
+		}
+		rows.Close()
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to load tasks for snapshot: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+			return
+		}
+
+		// ✅ 標籤會在還原時隨任務一起刪除（ON DELETE CASCADE），因此一併保存
+		if error := loadSnapshotLabels(transaction, identifier, userIdentifier, snapshotTasks, taskIndexes); error != nil {
+			log.Printf("❌ Failed to load task labels for snapshot: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+			return
+		}
+
+		payload, error := json.Marshal(snapshotTasks)
+		if error != nil {
+			log.Printf("❌ Failed to encode snapshot: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+			return
+		}
+
+		createdAt := time.Now().UTC().Truncate(time.Second)
+		result, error := transaction.Exec(
+			"INSERT INTO section_snapshots (section_id, user_id, tasks, task_count, created_at) VALUES (?, ?, ?, ?, ?)",
+			identifier, userIdentifier, payload, len(snapshotTasks), createdAt,
+		)
+		if error != nil {
+			log.Printf("❌ Failed to insert snapshot: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+			return
+		}
+		snapshotIdentifier, _ := result.LastInsertId()
+
+		if error := purgeSectionSnapshots(transaction, identifier); error != nil {
+			log.Printf("❌ Failed to purge old snapshots: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+			return
+		}
+
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+			return
+		}
+
+		log.Printf("✅ Section snapshot created: ID=%d, SectionID=%d, Tasks=%d", snapshotIdentifier, identifier, len(snapshotTasks))
+		context.JSON(http.StatusCreated, models.SectionSnapshot{
+			ID:        snapshotIdentifier,
+			SectionID: identifier,
+			TaskCount: len(snapshotTasks),
+			CreatedAt: createdAt,
+		})
+	}
+}
+
+// loadSnapshotLabels 讀取區塊內未刪除任務的標籤，依 taskIndexes（task ID → snapshotTasks 索引）填入對應任務
+func loadSnapshotLabels(transaction *sql.Tx, sectionIdentifier int64, userIdentifier int64, snapshotTasks []models.SnapshotTask, taskIndexes map[int64]int) error {
+	rows, error := transaction.Query(`
+		SELECT tl.task_id, tl.label
+		FROM task_labels tl
+		JOIN tasks t ON t.id = tl.task_id
+		WHERE t.section_id = ? AND t.user_id = ? AND t.deleted_at IS NULL
+		ORDER BY tl.label ASC`, sectionIdentifier, userIdentifier)
+	if error != nil {
+		return error
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskIdentifier int64
+		var label string
+		if error := rows.Scan(&taskIdentifier, &label); error != nil {
+			return error
+		}
+		if index, found := taskIndexes[taskIdentifier]; found {
+			snapshotTasks[index].Labels = append(snapshotTasks[index].Labels, label)
+		}
+	}
+	return rows.Err()
+}
+
+// purgeSectionSnapshots 刪除超過保留期限，以及超出每區塊上限的舊快照
+func purgeSectionSnapshots(transaction *sql.Tx, sectionIdentifier int64) error {
+	cutoff := time.Now().UTC().Add(-models.SnapshotRetention)
+	if _, error := transaction.Exec("DELETE FROM section_snapshots WHERE section_id = ? AND created_at < ?", sectionIdentifier, cutoff); error != nil {
+		return error
+	}
+
+	identifiers, error := queryOrderedIdentifiers(transaction, "SELECT id FROM section_snapshots WHERE section_id = ? ORDER BY created_at DESC, id DESC", sectionIdentifier)
+	if error != nil {
+		return error
+	}
+	if len(identifiers) <= models.MaxSnapshotsPerSection {
+		return nil
+	}
+
+	placeholders, args := buildInClause(identifiers[models.MaxSnapshotsPerSection:])
+	_, error = transaction.Exec("DELETE FROM section_snapshots WHERE id IN ("+placeholders+")", args...)
+	return error
+}
+
+// GetSectionSnapshots godoc
+// @Summary      取得區塊快照列表
+// @Description  列出區塊在保留期限內的快照，由新到舊排序
+// @ID           listSectionSnapshots
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      200  {array}   models.SectionSnapshot
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/sections/{id}/snapshots [get]
+func GetSectionSnapshots(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		// ✅ 確認該 section 是該使用者的
+		var exists bool
+		error = database.QueryRow("SELECT EXISTS (SELECT 1 FROM sections WHERE id = ? AND user_id = ?)", identifier, userIdentifier).Scan(&exists)
+		if error != nil {
+			log.Printf("❌ Failed to check section ownership: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch snapshots"})
+			return
+		}
+		if !exists {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Section not found"})
+			return
+		}
+
+		cutoff := time.Now().UTC().Add(-models.SnapshotRetention)
+		rows, error := database.Query(`
+			SELECT id, section_id, task_count, created_at
+			FROM section_snapshots
+			WHERE section_id = ? AND user_id = ? AND created_at >= ?
+			ORDER BY created_at DESC, id DESC`, identifier, userIdentifier, cutoff)
+		if error != nil {
+			log.Printf("❌ Failed to query snapshots: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch snapshots"})
+			return
+		}
+		defer rows.Close()
+
+		snapshots := []models.SectionSnapshot{}
+		for rows.Next() {
+			var snapshot models.SectionSnapshot
+			if error := rows.Scan(&snapshot.ID, &snapshot.SectionID, &snapshot.TaskCount, &snapshot.CreatedAt); error != nil {
+				log.Printf("❌ Failed to scan snapshot: %v", error)
+				continue
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+
+		context.JSON(http.StatusOK, snapshots)
+	}
+}
+
+// RestoreSectionSnapshot godoc
+// @Summary      將區塊還原到快照
+// @Description  刪除區塊目前所有任務，並依快照內容與排序重建任務與標籤（任務會取得新的 ID），整個過程在同一個 transaction 中完成。
+// @Description  垃圾桶中使用相同 external_ref 的任務會清除其 external_ref；其他未刪除的任務已使用時回傳 409
+// @ID           restoreSectionSnapshot
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path      int  true  "Section ID"
+// @Param        snapshotId  path      int  true  "Snapshot ID"
+// @Success      200         {object}  map[string]interface{}
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      409         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /plans/sections/{id}/restore-snapshot/{snapshotId} [post]
+func RestoreSectionSnapshot(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+			return
+		}
+		snapshotIdentifier, error := strconv.ParseInt(context.Param("snapshotId"), 10, 64)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot ID"})
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "DB transaction error"})
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 鎖定該使用者的 section，避免還原期間有其他寫入
+		var isClosed bool
+		error = transaction.QueryRow("SELECT is_closed FROM sections WHERE id = ? AND user_id = ? FOR UPDATE", identifier, userIdentifier).Scan(&isClosed)
+		if error == sql.ErrNoRows {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Section not found"})
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to check section ownership: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot"})
+			return
+		}
+		if isClosed {
+			context.JSON(http.StatusConflict, gin.H{"error": "Section is closed"})
+			return
+		}
+
+		cutoff := time.Now().UTC().Add(-models.SnapshotRetention)
+		var payload []byte
+		error = transaction.QueryRow(
+			"SELECT tasks FROM section_snapshots WHERE id = ? AND section_id = ? AND user_id = ? AND created_at >= ?",
+			snapshotIdentifier, identifier, userIdentifier, cutoff,
+		).Scan(&payload)
+		if error == sql.ErrNoRows {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to load snapshot: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot"})
+			return
+		}
+
+		var snapshotTasks []models.SnapshotTask
+		if error := json.Unmarshal(payload, &snapshotTasks); error != nil {
+			log.Printf("❌ Failed to decode snapshot %d: %v", snapshotIdentifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot"})
+			return
+		}
+
+		if _, error := transaction.Exec("DELETE FROM tasks WHERE section_id = ? AND user_id = ? AND deleted_at IS NULL", identifier, userIdentifier); error != nil {
+			log.Printf("❌ Failed to clear section tasks: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot"})
+			return
+		}
+
+		for index, task := range snapshotTasks {
+			if error := models.ReleaseTrashedExternalRef(transaction, userIdentifier, task.ExternalRef); error != nil {
+				log.Printf("❌ Failed to release trashed external_ref: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot"})
+				return
+			}
+
+			result, error := transaction.Exec(
+				"INSERT INTO tasks (user_id, section_id, title, content, is_completed, priority, completed_at, sort_order, external_ref, due_date, deferred_until) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				userIdentifier, identifier, task.Title, task.Content, task.IsCompleted, taskPriority(task.Priority), task.CompletedAt, task.SortOrder, task.ExternalRef, task.DueDate, task.DeferredUntil,
+			)
+			// 快照之後其他區塊的任務可能已使用相同的 external_ref
+			if models.IsDuplicateEntry(error) {
+				context.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("tasks[%d].external_ref already used by another task", index)})
+				return
+			}
+			if error != nil {
+				log.Printf("❌ Failed to restore task: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot"})
+				return
+			}
+
+			taskIdentifier, _ := result.LastInsertId()
+			for _, label := range task.Labels {
+				if error := models.AttachTaskLabel(transaction, taskIdentifier, label); error != nil {
+					log.Printf("❌ Failed to restore label of task %d: %v", taskIdentifier, error)
+					context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot"})
+					return
+				}
+			}
+		}
+
+		if _, error := transaction.Exec("UPDATE sections SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", identifier); error != nil {
+			log.Printf("❌ Failed to touch section: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot"})
+			return
+		}
+
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+			return
+		}
+
+		log.Printf("✅ Section restored from snapshot: SectionID=%d, SnapshotID=%d, Tasks=%d", identifier, snapshotIdentifier, len(snapshotTasks))
+		context.JSON(http.StatusOK, gin.H{
+			"message":     "Section restored",
+			"id":          identifier,
+			"snapshot_id": snapshotIdentifier,
+			"task_count":  len(snapshotTasks),
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+func newRestoreSnapshotRouter(t *testing.T, payload string) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.POST("/plans/sections/:id/restore-snapshot/:snapshotId", withUser(1), RestoreSectionSnapshot(database))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT is_closed FROM sections WHERE id = \\? AND user_id = \\? FOR UPDATE").WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"is_closed"}).AddRow(false))
+	mock.ExpectQuery("SELECT tasks FROM section_snapshots").WithArgs(3, 10, 1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"tasks"}).AddRow([]byte(payload)))
+	mock.ExpectExec("DELETE FROM tasks WHERE section_id = \\? AND user_id = \\? AND deleted_at IS NULL").WithArgs(10, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	return router, mock
+}
+
+func TestRestoreSectionSnapshotReleasesTrashedExternalRef(t *testing.T) {
+	router, mock := newRestoreSnapshotRouter(t, `[{"title":"Sync","sort_order":1,"external_ref":"JIRA-1"}]`)
+
+	// 快照之後刪除的任務仍在垃圾桶中佔用 JIRA-1，還原前先清除
+	mock.ExpectExec("UPDATE tasks SET external_ref = NULL WHERE user_id = \\? AND external_ref = \\? AND deleted_at IS NOT NULL").
+		WithArgs(1, "JIRA-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO tasks").WillReturnResult(sqlmock.NewResult(20, 1))
+	mock.ExpectExec("UPDATE sections SET updated_at").WithArgs(10).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	recorder := performRequest(router, http.MethodPost, "/plans/sections/10/restore-snapshot/3", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestRestoreSectionSnapshotExternalRefInUseConflicts(t *testing.T) {
+	router, mock := newRestoreSnapshotRouter(t, `[{"title":"Sync","sort_order":1,"external_ref":"JIRA-1"}]`)
+
+	// 其他區塊未刪除的任務已使用 JIRA-1
+	mock.ExpectExec("UPDATE tasks SET external_ref = NULL").WithArgs(1, "JIRA-1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO tasks").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-JIRA-1' for key 'tasks.idx_tasks_user_external_ref'"})
+	mock.ExpectRollback()
+
+	recorder := performRequest(router, http.MethodPost, "/plans/sections/10/restore-snapshot/3", "")
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestRestoreSectionSnapshotReinsertsLabels(t *testing.T) {
+	router, mock := newRestoreSnapshotRouter(t, `[{"title":"Sync","sort_order":1,"labels":["home","work"]}]`)
+
+	mock.ExpectExec("INSERT INTO tasks").WillReturnResult(sqlmock.NewResult(20, 1))
+	// 還原時刪除的任務標籤已隨 ON DELETE CASCADE 消失，必須依快照重新加上
+	mock.ExpectExec("INSERT IGNORE INTO task_labels \\(task_id, label\\) VALUES \\(\\?, \\?\\)").WithArgs(20, "home").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT IGNORE INTO task_labels \\(task_id, label\\) VALUES \\(\\?, \\?\\)").WithArgs(20, "work").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE sections SET updated_at").WithArgs(10).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	recorder := performRequest(router, http.MethodPost, "/plans/sections/10/restore-snapshot/3", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestCreateSectionSnapshotCapturesLabels(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.POST("/plans/sections/:id/snapshots", withUser(1), CreateSectionSnapshot(database))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM sections WHERE id = \\? AND user_id = \\?\\)").WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT id, title, content, is_completed, priority, completed_at, sort_order, external_ref, due_date, deferred_until").WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "is_completed", "priority", "completed_at", "sort_order", "external_ref", "due_date", "deferred_until"}).
+			AddRow(20, "Sync", "", false, "medium", nil, 1, nil, nil, nil).
+			AddRow(21, "Plan", "", false, "high", nil, 2, nil, nil, nil))
+	mock.ExpectQuery("SELECT tl.task_id, tl.label\\s+FROM task_labels tl").WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "label"}).AddRow(21, "home").AddRow(20, "work"))
+	mock.ExpectExec("INSERT INTO section_snapshots").
+		WithArgs(10, 1, snapshotPayloadMatcher{want: `[{"title":"Sync","content":"","is_completed":false,"priority":"medium","sort_order":1,"labels":["work"]},{"title":"Plan","content":"","is_completed":false,"priority":"high","sort_order":2,"labels":["home"]}]`}, 2, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("DELETE FROM section_snapshots WHERE section_id = \\? AND created_at < \\?").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id FROM section_snapshots").WithArgs(10).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+
+	recorder := performRequest(router, http.MethodPost, "/plans/sections/10/snapshots", "")
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+// snapshotPayloadMatcher 比對寫入 section_snapshots.tasks 的 JSON 內容
+type snapshotPayloadMatcher struct {
+	want string
+}
+
+func (matcher snapshotPayloadMatcher) Match(value driver.Value) bool {
+	payload, isBytes := value.([]byte)
+	return isBytes && string(payload) == matcher.want
+}
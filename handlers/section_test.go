@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func newSectionsWithTasksRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.PUT("/plans/sections-with-tasks", withUser(1), UpdateSectionsWithTasks(database))
+	return router, mock
+}
+
+func TestUpdateSectionsWithTasksStaleSectionVersionConflicts(t *testing.T) {
+	router, mock := newSectionsWithTasksRouter(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, is_closed FROM sections WHERE id = \\?").WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "is_closed"}).AddRow(1, false))
+	// 另一個請求已經把版本改成 3，帶著版本 2 的更新不會影響任何資料列
+	mock.ExpectExec("UPDATE sections SET sort_order = \\?, version = version \\+ 1 WHERE id = \\? AND version = \\?").
+		WithArgs(1, 10, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	recorder := performRequest(router, http.MethodPut, "/plans/sections-with-tasks", `[{"id":10,"version":2,"tasks":[]}]`)
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if code := decodeAPIError(t, recorder).Code; code != CodePlanModified {
+		t.Fatalf("expected %s, got %s", CodePlanModified, code)
+	}
+}
+
+func TestUpdateSectionsWithTasksStaleTaskVersionConflicts(t *testing.T) {
+	router, mock := newSectionsWithTasksRouter(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, is_closed FROM sections WHERE id = \\?").WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "is_closed"}).AddRow(1, false))
+	mock.ExpectExec("UPDATE sections SET sort_order").WithArgs(1, 10, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT section_id FROM tasks WHERE id = \\? AND user_id = \\? AND deleted_at IS NULL").WithArgs(20, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"section_id"}).AddRow(10))
+	mock.ExpectExec("UPDATE tasks SET section_id = \\?, sort_order = \\?, version = version \\+ 1 WHERE id = \\? AND version = \\?").
+		WithArgs(10, 1, 20, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+	// 任務版本不符時，前面已更新的區塊排序也必須一起 rollback
+	mock.ExpectRollback()
+
+	recorder := performRequest(router, http.MethodPut, "/plans/sections-with-tasks", `[{"id":10,"version":2,"tasks":[{"id":20,"version":5}]}]`)
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if code := decodeAPIError(t, recorder).Code; code != CodePlanModified {
+		t.Fatalf("expected %s, got %s", CodePlanModified, code)
+	}
+}
+
+func TestUpdateSectionsWithTasksRejectsAnotherUsersTask(t *testing.T) {
+	router, mock := newSectionsWithTasksRouter(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, is_closed FROM sections WHERE id = \\?").WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "is_closed"}).AddRow(1, false))
+	mock.ExpectExec("UPDATE sections SET sort_order").WithArgs(1, 10, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	// 任務 30 屬於其他使用者，查詢以 user_id 篩選後找不到，不能被移進自己的區塊
+	mock.ExpectQuery("SELECT section_id FROM tasks WHERE id = \\? AND user_id = \\? AND deleted_at IS NULL").WithArgs(30, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"section_id"}))
+	mock.ExpectRollback()
+
+	recorder := performRequest(router, http.MethodPut, "/plans/sections-with-tasks", `[{"id":10,"version":2,"tasks":[{"id":30,"version":1}]}]`)
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if code := decodeAPIError(t, recorder).Code; code != CodeTaskNotFound {
+		t.Fatalf("expected %s, got %s", CodeTaskNotFound, code)
+	}
+}
+
+func newGetSectionsRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/plans/sections", withUser(1), GetSections(database))
+	return router, mock
+}
+
+func sectionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "title", "sort_order", "is_closed", "archived", "version", "created_at", "updated_at"}).
+		AddRow(10, "Inbox", 1, false, false, 1, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC))
+}
+
+func TestGetSectionsFiltersByFromAndTo(t *testing.T) {
+	router, mock := newGetSectionsRouter(t)
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 31, 16, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("WHERE user_id = \\? AND archived = FALSE AND created_at BETWEEN \\? AND \\?").
+		WithArgs(1, from, to).WillReturnRows(sectionRows())
+
+	// to 帶 +08:00 時區，查詢時一律轉成 UTC
+	recorder := performRequest(router, http.MethodGet, "/plans/sections?from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00%2B08:00", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestGetSectionsFiltersByFromOnly(t *testing.T) {
+	router, mock := newGetSectionsRouter(t)
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("WHERE user_id = \\? AND archived = FALSE AND created_at >= \\?").
+		WithArgs(1, from).WillReturnRows(sectionRows())
+
+	recorder := performRequest(router, http.MethodGet, "/plans/sections?from=2026-01-01T00:00:00Z", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestGetSectionsRejectsInvalidRange(t *testing.T) {
+	for name, query := range map[string]string{
+		"from after to": "?from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+		"malformed to":  "?to=yesterday",
+	} {
+		t.Run(name, func(t *testing.T) {
+			router, _ := newGetSectionsRouter(t)
+
+			recorder := performRequest(router, http.MethodGet, "/plans/sections"+query, "")
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", recorder.Code, recorder.Body.String())
+			}
+			if code := decodeAPIError(t, recorder).Code; code != CodeInvalidInput {
+				t.Fatalf("expected %s, got %s", CodeInvalidInput, code)
+			}
+		})
+	}
+}
+
+func TestGetSectionPriorityBreakdownZeroFillsBuckets(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/plans/sections/:id/priority-breakdown", withUser(1), GetSectionPriorityBreakdown(database))
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM sections WHERE id = \\? AND user_id = \\?\\)").WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	// 沒有 low 的任務，low 必須補 0
+	mock.ExpectQuery("SELECT priority, is_completed, COUNT\\(\\*\\)\\s+FROM tasks\\s+WHERE section_id = \\? AND deleted_at IS NULL\\s+GROUP BY priority, is_completed").WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"priority", "is_completed", "count"}).
+			AddRow("high", false, 2).
+			AddRow("high", true, 1).
+			AddRow("medium", true, 4))
+
+	recorder := performRequest(router, http.MethodGet, "/plans/sections/10/priority-breakdown", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var breakdown models.SectionPriorityBreakdown
+	if err := json.Unmarshal(recorder.Body.Bytes(), &breakdown); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := models.SectionPriorityBreakdown{
+		SectionID:    10,
+		Total:        7,
+		ByPriority:   models.PriorityCounts{Low: 0, Medium: 4, High: 3},
+		ByCompletion: models.CompletionCounts{Completed: 5, Incomplete: 2},
+	}
+	if breakdown != want {
+		t.Fatalf("expected %+v, got %+v", want, breakdown)
+	}
+}
+
+func TestGetSectionPriorityBreakdownOtherUsersSection(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/plans/sections/:id/priority-breakdown", withUser(1), GetSectionPriorityBreakdown(database))
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM sections WHERE id = \\? AND user_id = \\?\\)").WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	recorder := performRequest(router, http.MethodGet, "/plans/sections/10/priority-breakdown", "")
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestDeleteSectionReordersInSingleStatement(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.DELETE("/plans/sections/:id", withUser(1), DeleteSection(database))
+
+	mock.ExpectQuery("SELECT 1 FROM sections WHERE id = \\? AND user_id = \\?").WithArgs("3", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec("DELETE FROM sections WHERE id = \\? AND user_id = \\?").WithArgs("3", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 重排不依賴 session 變數，連線池換連線也不會影響結果
+	mock.ExpectExec("UPDATE sections s\\s+JOIN \\(\\s+SELECT id, ROW_NUMBER\\(\\) OVER \\(ORDER BY sort_order ASC, id ASC\\) AS new_sort\\s+FROM sections\\s+WHERE user_id = \\? AND archived = FALSE").
+		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	recorder := performRequest(router, http.MethodDelete, "/plans/sections/3", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Walter1412/micro-backend/models"
@@ -13,13 +16,17 @@ import (
 // CreateTask godoc
 // @Summary      建立任務（Task）
 // @Description  建立新的任務，並自動排序
+// @ID           createTask
 // @Tags         Plans
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        task  body  models.CreateTaskInput  true  "任務內容"
-// @Success      200   {object}  map[string]interface{}
+// @Success      201   {object}  map[string]interface{}
+// @Header       201   {string}  Location  "新任務的 URL"
 // @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
 // @Router       /plans/tasks [post]
 func CreateTask(database *sql.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
@@ -32,18 +39,32 @@ func CreateTask(database *sql.DB) gin.HandlerFunc {
 
 		userIdentifier := context.GetInt64("user_id")
 
-		// ✅ 驗證該 section 是否屬於該 user
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "DB transaction error"})
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 驗證該 section 是否屬於該 user，並鎖定該 section，避免同時建立時 sort_order 重複
 		var ownerIdentifier int64
-		error := database.QueryRow("SELECT user_id FROM sections WHERE id = ?", input.SectionID).Scan(&ownerIdentifier)
+		var isClosed bool
+		error = transaction.QueryRow("SELECT user_id, is_closed FROM sections WHERE id = ? FOR UPDATE", input.SectionID).Scan(&ownerIdentifier, &isClosed)
 		if error != nil || ownerIdentifier != userIdentifier {
 			log.Printf("❌ Unauthorized to access section_id=%d by user_id=%d", input.SectionID, userIdentifier)
 			context.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to add task to this section"})
 			return
 		}
+		if isClosed {
+			log.Printf("❌ Cannot add task to closed section_id=%d", input.SectionID)
+			context.JSON(http.StatusConflict, gin.H{"error": "Section is closed"})
+			return
+		}
 
 		// ✅ 查詢目前 section 下最大的 sort_order
 		var maxSort sql.NullInt64
-		error = database.QueryRow("SELECT MAX(sort_order) FROM tasks WHERE section_id = ?", input.SectionID).Scan(&maxSort)
+		error = transaction.QueryRow("SELECT MAX(sort_order) FROM tasks WHERE section_id = ? AND deleted_at IS NULL", input.SectionID).Scan(&maxSort)
 		if error != nil {
 			log.Printf("❌ Failed to get max sort: %v", error)
 			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get max sort"})
@@ -55,34 +76,188 @@ func CreateTask(database *sql.DB) gin.HandlerFunc {
 			newSort = int(maxSort.Int64) + 1
 		}
 
+		externalRef := normalizeExternalRef(input.ExternalRef)
+		if error := models.ReleaseTrashedExternalRef(transaction, userIdentifier, externalRef); error != nil {
+			log.Printf("❌ Failed to release trashed external_ref: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+			return
+		}
+
 		now := time.Now()
-		result, error := database.Exec(`
-			INSERT INTO tasks (user_id, section_id, title, content, is_completed, sort_order, created_at, updated_at)
-			VALUES (?, ?, ?, ?, false, ?, ?, ?)`,
-			userIdentifier, input.SectionID, input.Title, input.Content, newSort, now, now,
+		result, error := transaction.Exec(`
+			INSERT INTO tasks (user_id, section_id, title, content, external_ref, due_date, deferred_until, is_completed, priority, sort_order, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, false, ?, ?, ?, ?)`,
+			userIdentifier, input.SectionID, input.Title, input.Content, externalRef, utcTime(input.DueDate), utcTime(input.DeferredUntil), taskPriority(input.Priority), newSort, now, now,
 		)
+		if models.IsDuplicateEntry(error) {
+			context.JSON(http.StatusConflict, gin.H{"error": "external_ref already used by another task"})
+			return
+		}
 		if error != nil {
 			log.Printf("❌ Failed to insert task: %v", error)
 			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
 			return
 		}
 
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+			return
+		}
+
 		identifier, _ := result.LastInsertId()
 		log.Printf("✅ Task created: ID=%d, SectionID=%d", identifier, input.SectionID)
-		context.JSON(http.StatusOK, gin.H{
-			"id":           identifier,
-			"section_id":   input.SectionID,
-			"title":        input.Title,
-			"content":      input.Content,
-			"sort_order":   newSort,
-			"is_completed": false,
-		})
+		response := gin.H{
+			"id":             identifier,
+			"section_id":     input.SectionID,
+			"title":          input.Title,
+			"content":        input.Content,
+			"sort_order":     newSort,
+			"is_completed":   false,
+			"priority":       taskPriority(input.Priority),
+			"external_ref":   externalRef,
+			"due_date":       utcTime(input.DueDate),
+			"deferred_until": utcTime(input.DeferredUntil),
+		}
+		if input.ClientID != "" {
+			response["client_id"] = input.ClientID
+		}
+		context.Header("Location", resourceLocation(context, identifier))
+		context.JSON(http.StatusCreated, response)
+	}
+}
+
+// maxBatchTasks 為單次批次建立任務的上限
+const maxBatchTasks = 200
+
+// CreateTasksBatch godoc
+// @Summary      批次建立任務（Task）
+// @Description  一次建立多個任務，所有 section_id 都必須屬於本人且未關閉；每個區塊的 sort_order 接在現有任務之後依序遞增，全部在同一個 transaction 中完成（單次最多 200 筆）
+// @ID           createTasksBatch
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tasks  body      []models.CreateTaskInput  true  "任務內容"
+// @Success      201    {array}   models.Task
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      409    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /plans/tasks/batch [post]
+func CreateTasksBatch(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var inputs []models.CreateTaskInput
+		if error := context.ShouldBindJSON(&inputs); error != nil {
+			log.Printf("❌ Invalid input: %v", error)
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if len(inputs) == 0 || len(inputs) > maxBatchTasks {
+			context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("tasks must contain 1 to %d items", maxBatchTasks)})
+			return
+		}
+
+		userIdentifier := context.GetInt64("user_id")
+
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "DB transaction error"})
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 先驗證所有 section 都屬於該 user，並鎖定以取得各自的下一個 sort_order
+		nextSorts := make(map[int64]int)
+		for _, input := range inputs {
+			if _, checked := nextSorts[input.SectionID]; checked {
+				continue
+			}
+
+			var ownerIdentifier int64
+			var isClosed bool
+			error := transaction.QueryRow("SELECT user_id, is_closed FROM sections WHERE id = ? FOR UPDATE", input.SectionID).Scan(&ownerIdentifier, &isClosed)
+			if error != nil && error != sql.ErrNoRows {
+				log.Printf("❌ Failed to query section %d: %v", input.SectionID, error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tasks"})
+				return
+			}
+			if error == sql.ErrNoRows || ownerIdentifier != userIdentifier {
+				log.Printf("❌ Unauthorized to access section_id=%d by user_id=%d", input.SectionID, userIdentifier)
+				context.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Unauthorized to add task to section %d", input.SectionID)})
+				return
+			}
+			if isClosed {
+				context.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Section %d is closed", input.SectionID)})
+				return
+			}
+
+			var maxSort sql.NullInt64
+			if error := transaction.QueryRow("SELECT MAX(sort_order) FROM tasks WHERE section_id = ? AND deleted_at IS NULL", input.SectionID).Scan(&maxSort); error != nil {
+				log.Printf("❌ Failed to get max sort: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get max sort"})
+				return
+			}
+			nextSorts[input.SectionID] = int(maxSort.Int64) + 1
+		}
+
+		identifiers := make([]int64, 0, len(inputs))
+		for index, input := range inputs {
+			externalRef := normalizeExternalRef(input.ExternalRef)
+			if error := models.ReleaseTrashedExternalRef(transaction, userIdentifier, externalRef); error != nil {
+				log.Printf("❌ Failed to release trashed external_ref: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tasks"})
+				return
+			}
+
+			result, error := transaction.Exec(`
+				INSERT INTO tasks (user_id, section_id, title, content, external_ref, due_date, deferred_until, priority, is_completed, completed_at, sort_order)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CASE WHEN ? THEN CURRENT_TIMESTAMP ELSE NULL END, ?)`,
+				userIdentifier, input.SectionID, input.Title, input.Content, externalRef, utcTime(input.DueDate), utcTime(input.DeferredUntil),
+				taskPriority(input.Priority), input.IsCompleted, input.IsCompleted, nextSorts[input.SectionID],
+			)
+			if models.IsDuplicateEntry(error) {
+				context.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("tasks[%d].external_ref already used by another task", index)})
+				return
+			}
+			if error != nil {
+				log.Printf("❌ Failed to insert task: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tasks"})
+				return
+			}
+			nextSorts[input.SectionID]++
+
+			insertedIdentifier, _ := result.LastInsertId()
+			identifiers = append(identifiers, insertedIdentifier)
+		}
+
+		tasks := make([]models.Task, 0, len(identifiers))
+		for _, identifier := range identifiers {
+			task, error := scanTask(transaction.QueryRow("SELECT "+taskColumns+" FROM tasks t WHERE t.id = ?", identifier))
+			if error != nil {
+				log.Printf("❌ Failed to read created task %d: %v", identifier, error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tasks"})
+				return
+			}
+			tasks = append(tasks, task)
+		}
+
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+			return
+		}
+
+		log.Printf("✅ Tasks batch created: Count=%d, UserID=%d", len(tasks), userIdentifier)
+		context.JSON(http.StatusCreated, tasks)
 	}
 }
 
 // UpdateTask godoc
 // @Summary      更新任務（Task）
-// @Description  根據 ID 更新任務內容
+// @Description  根據 ID 更新任務內容，只會更新請求中有提供的欄位
+// @ID           updateTask
 // @Tags         Plans
 // @Security     BearerAuth
 // @Accept       json
@@ -92,6 +267,7 @@ func CreateTask(database *sql.DB) gin.HandlerFunc {
 // @Success      200   {object}  map[string]string
 // @Failure      400   {object}  map[string]string
 // @Failure      403   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
 // @Failure      500   {object}  map[string]string
 // @Router       /plans/tasks/{id} [put]
 func UpdateTask(database *sql.DB) gin.HandlerFunc {
@@ -104,10 +280,108 @@ func UpdateTask(database *sql.DB) gin.HandlerFunc {
 			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 			return
 		}
+		if input.Title == nil && input.Content == nil && input.IsCompleted == nil && input.Priority == nil && input.ExternalRef == nil && !input.DueDate.Set && input.DeferredUntil == nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+			return
+		}
+
+		// ✅ 確認 task 是否屬於該 user
+		var taskOwnerIdentifier int64
+		error := database.QueryRow("SELECT user_id FROM tasks WHERE id = ? AND deleted_at IS NULL", identifier).Scan(&taskOwnerIdentifier)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Task not found"})
+			return
+		}
+		if taskOwnerIdentifier != userIdentifier {
+			context.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to modify this task"})
+			return
+		}
+
+		// ✅ 只更新有提供的欄位（完成時記錄 completed_at，取消完成則清空）
+		var assignments []string
+		var args []interface{}
+		if input.Title != nil {
+			assignments = append(assignments, "title = ?")
+			args = append(args, *input.Title)
+		}
+		if input.Content != nil {
+			assignments = append(assignments, "content = ?")
+			args = append(args, *input.Content)
+		}
+		if input.IsCompleted != nil {
+			assignments = append(assignments,
+				"is_completed = ?",
+				"completed_at = CASE WHEN ? THEN COALESCE(completed_at, CURRENT_TIMESTAMP) ELSE NULL END")
+			args = append(args, *input.IsCompleted, *input.IsCompleted)
+		}
+		if input.Priority != nil {
+			assignments = append(assignments, "priority = ?")
+			args = append(args, *input.Priority)
+		}
+		if input.ExternalRef != nil {
+			externalRef := normalizeExternalRef(input.ExternalRef)
+			if error := models.ReleaseTrashedExternalRef(database, taskOwnerIdentifier, externalRef); error != nil {
+				log.Printf("❌ Failed to release trashed external_ref: %v", error)
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+				return
+			}
+			assignments = append(assignments, "external_ref = ?")
+			args = append(args, externalRef)
+		}
+		if input.DueDate.Set {
+			assignments = append(assignments, "due_date = ?")
+			args = append(args, utcTime(input.DueDate.Value))
+		}
+		if input.DeferredUntil != nil {
+			assignments = append(assignments, "deferred_until = ?")
+			args = append(args, utcTime(input.DeferredUntil))
+		}
+		assignments = append(assignments, "updated_at = CURRENT_TIMESTAMP")
+		args = append(args, identifier)
+
+		_, error = database.Exec("UPDATE tasks SET "+strings.Join(assignments, ", ")+" WHERE id = ?", args...)
+		if models.IsDuplicateEntry(error) {
+			context.JSON(http.StatusConflict, gin.H{"error": "external_ref already used by another task"})
+			return
+		}
+		if error != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+			return
+		}
+
+		context.JSON(http.StatusOK, gin.H{"message": "Task updated"})
+	}
+}
+
+// SetTaskCompleted godoc
+// @Summary      切換任務完成狀態
+// @Description  只更新任務的完成狀態（與完成時間），不會動到標題與內容
+// @ID           setTaskCompleted
+// @Tags         Plans
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int                          true  "任務 ID"
+// @Param        body  body  models.SetTaskCompletedInput true  "完成狀態"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /plans/tasks/{id}/complete [patch]
+func SetTaskCompleted(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier := context.Param("id")
+		userIdentifier := context.GetInt64("user_id")
+
+		var input models.SetTaskCompletedInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
 
 		// ✅ 確認 task 是否屬於該 user
 		var taskOwnerIdentifier int64
-		error := database.QueryRow("SELECT user_id FROM tasks WHERE id = ?", identifier).Scan(&taskOwnerIdentifier)
+		error := database.QueryRow("SELECT user_id FROM tasks WHERE id = ? AND deleted_at IS NULL", identifier).Scan(&taskOwnerIdentifier)
 		if error != nil {
 			context.JSON(http.StatusBadRequest, gin.H{"error": "Task not found"})
 			return
@@ -117,23 +391,307 @@ func UpdateTask(database *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// ✅ 更新 task
+		// ✅ 只更新完成狀態（完成時記錄 completed_at，取消完成則清空）
 		_, error = database.Exec(`
 			UPDATE tasks
-			SET title = ?, content = ?, is_completed = ?, updated_at = CURRENT_TIMESTAMP
-			WHERE id = ?`, input.Title, input.Content, input.IsCompleted, identifier)
+			SET is_completed = ?,
+				completed_at = CASE WHEN ? THEN COALESCE(completed_at, CURRENT_TIMESTAMP) ELSE NULL END,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`, *input.IsCompleted, *input.IsCompleted, identifier)
 		if error != nil {
+			log.Printf("❌ Failed to update task %s completion: %v", identifier, error)
 			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 			return
 		}
 
-		context.JSON(http.StatusOK, gin.H{"message": "Task updated"})
+		context.JSON(http.StatusOK, gin.H{
+			"message":      "Task updated",
+			"id":           identifier,
+			"is_completed": *input.IsCompleted,
+		})
+	}
+}
+
+// MoveTask godoc
+// @Summary      移動任務到其他區塊
+// @Description  將任務移到指定區塊的指定位置，並重新排序來源與目標區塊內的任務；任務與目標區塊都必須屬於本人，目標區塊不可為關閉狀態
+// @ID           moveTask
+// @Tags         Plans
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int                   true  "任務 ID"
+// @Param        body  body  models.MoveTaskInput  true  "目標區塊與位置"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /plans/tasks/{id}/move [put]
+func MoveTask(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		var input models.MoveTaskInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			log.Printf("❌ Invalid input: %v", error)
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "DB transaction error"})
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 與批次端點共用 move 邏輯（擁有權檢查、關閉區塊檢查、來源與目標區塊重排）
+		executor := &batchExecutor{
+			transaction:    transaction,
+			userIdentifier: userIdentifier,
+			tempIDs:        make(map[string]int64),
+		}
+		_, error = executor.moveTask(models.BatchOperation{
+			ID:        &identifier,
+			SectionID: &input.SectionID,
+			SortOrder: input.SortOrder,
+		})
+		if error != nil {
+			if batchErr, isValid := error.(*batchError); isValid {
+				context.JSON(batchErr.status, gin.H{"error": batchErr.message})
+				return
+			}
+			log.Printf("❌ Failed to move task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move task"})
+			return
+		}
+
+		var sortOrder int
+		if error := transaction.QueryRow("SELECT sort_order FROM tasks WHERE id = ?", identifier).Scan(&sortOrder); error != nil {
+			log.Printf("❌ Failed to read moved task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move task"})
+			return
+		}
+
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+			return
+		}
+
+		log.Printf("✅ Task moved: ID=%d, SectionID=%d, Sort=%d", identifier, input.SectionID, sortOrder)
+		context.JSON(http.StatusOK, gin.H{
+			"message":    "Task moved",
+			"id":         identifier,
+			"section_id": input.SectionID,
+			"sort_order": sortOrder,
+		})
+	}
+}
+
+// DeferTask godoc
+// @Summary      延後任務
+// @Description  設定任務的 deferred_until，時間未到前任務不會出現在預設列表中（需帶 include_deferred=true）；帶 null 取消延後
+// @ID           deferTask
+// @Tags         Plans
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int                    true  "任務 ID"
+// @Param        body  body  models.DeferTaskInput  true  "延後到的時間（RFC 3339）"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /plans/tasks/{id}/defer [put]
+func DeferTask(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier := context.Param("id")
+		userIdentifier := context.GetInt64("user_id")
+
+		var input models.DeferTaskInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		// ✅ 確認 task 是否屬於該 user
+		var taskOwnerIdentifier int64
+		error := database.QueryRow("SELECT user_id FROM tasks WHERE id = ? AND deleted_at IS NULL", identifier).Scan(&taskOwnerIdentifier)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Task not found"})
+			return
+		}
+		if taskOwnerIdentifier != userIdentifier {
+			context.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to modify this task"})
+			return
+		}
+
+		deferredUntil := utcTime(input.DeferredUntil)
+		_, error = database.Exec("UPDATE tasks SET deferred_until = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", deferredUntil, identifier)
+		if error != nil {
+			log.Printf("❌ Failed to defer task %s: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+			return
+		}
+
+		context.JSON(http.StatusOK, gin.H{
+			"message":        "Task updated",
+			"id":             identifier,
+			"deferred_until": deferredUntil,
+		})
+	}
+}
+
+// taskPriority 回傳要寫入的優先度，未提供時使用預設值
+func taskPriority(priority string) string {
+	if priority == "" {
+		return models.DefaultTaskPriority
+	}
+	return priority
+}
+
+func isValidTaskPriority(priority string) bool {
+	return priority == "low" || priority == "medium" || priority == "high"
+}
+
+// utcTime 將客戶端帶的時間（可含任意時區）轉成 UTC 存放，nil 代表 NULL
+func utcTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	converted := value.UTC()
+	return &converted
+}
+
+// GetTaskByExternalRef godoc
+// @Summary      以外部參照取得任務
+// @Description  依建立或更新時設定的 external_ref 找出本人的任務，方便外部系統同步
+// @ID           getTaskByExternalRef
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ref  path      string  true  "外部參照（external_ref）"
+// @Success      200  {object}  models.Task
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/tasks/by-ref/{ref} [get]
+func GetTaskByExternalRef(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		externalRef := context.Param("ref")
+		userIdentifier := context.GetInt64("user_id")
+
+		task, error := scanTask(database.QueryRowContext(context.Request.Context(), "SELECT "+taskColumns+" FROM tasks t WHERE t.user_id = ? AND t.external_ref = ? AND t.deleted_at IS NULL", userIdentifier, externalRef))
+		if error == sql.ErrNoRows {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to query task by external_ref: %v", error)
+			respondServerError(context, "Failed to fetch task")
+			return
+		}
+
+		context.JSON(http.StatusOK, task)
+	}
+}
+
+// normalizeExternalRef 將未提供或空白的 external_ref 轉成 NULL
+func normalizeExternalRef(externalRef *string) *string {
+	if externalRef == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*externalRef)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// GetTask godoc
+// @Summary      取得單一任務（Task）
+// @Description  根據 ID 取得任務，僅限本人的任務
+// @ID           getTask
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id               path      int   true   "任務 ID"
+// @Param        include_deleted  query     bool  false  "包含已刪除（可還原）的任務"
+// @Success      200  {object}  models.Task
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/tasks/{id} [get]
+func GetTask(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		includeDeleted := false
+		if raw := context.Query("include_deleted"); raw != "" {
+			includeDeleted, error = strconv.ParseBool(raw)
+			if error != nil {
+				context.JSON(http.StatusBadRequest, gin.H{"error": "include_deleted must be true or false"})
+				return
+			}
+		}
+
+		// ✅ 查出 task 所屬 section 的擁有者 user_id
+		var taskOwnerIdentifier int64
+		var deletedAt sql.NullTime
+		error = database.QueryRowContext(context.Request.Context(), `
+			SELECT s.user_id, t.deleted_at
+			FROM tasks t
+			JOIN sections s ON t.section_id = s.id
+			WHERE t.id = ?`, identifier).Scan(&taskOwnerIdentifier, &deletedAt)
+		if error == nil && deletedAt.Valid && !includeDeleted {
+			error = sql.ErrNoRows
+		}
+		if error == sql.ErrNoRows {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to query task owner: %v", error)
+			respondServerError(context, "Failed to fetch task")
+			return
+		}
+
+		// ✅ 檢查擁有權
+		if taskOwnerIdentifier != userIdentifier {
+			log.Printf("❌ Unauthorized to read task ID=%d by user_id=%d", identifier, userIdentifier)
+			context.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to access this task"})
+			return
+		}
+
+		task, error := scanTask(database.QueryRowContext(context.Request.Context(), "SELECT "+taskColumns+" FROM tasks t WHERE t.id = ?", identifier))
+		if error != nil {
+			log.Printf("❌ Failed to query task %d: %v", identifier, error)
+			respondServerError(context, "Failed to fetch task")
+			return
+		}
+
+		context.JSON(http.StatusOK, task)
 	}
 }
 
 // DeleteTask godoc
 // @Summary      刪除任務（Task）
-// @Description  根據 ID 刪除任務，並重新排序同區塊內的任務
+// @Description  根據 ID 將任務移到垃圾桶（軟刪除），並重新排序同區塊內的任務；30 天內可用 restore 還原
+// @ID           deleteTask
 // @Tags         Plans
 // @Security     BearerAuth
 // @Param        id   path  int  true  "任務 ID"
@@ -154,7 +712,7 @@ func DeleteTask(database *sql.DB) gin.HandlerFunc {
 			SELECT s.id, s.user_id
 			FROM tasks t
 			JOIN sections s ON t.section_id = s.id
-			WHERE t.id = ?`, identifier).Scan(&sectionIdentifier, &taskOwnerIdentifier)
+			WHERE t.id = ? AND t.deleted_at IS NULL`, identifier).Scan(&sectionIdentifier, &taskOwnerIdentifier)
 		if error != nil {
 			log.Printf("❌ Invalid task ID or join failed: %v", error)
 			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
@@ -168,8 +726,8 @@ func DeleteTask(database *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// ✅ 刪除該任務
-		_, error = database.Exec("DELETE FROM tasks WHERE id = ?", identifier)
+		// ✅ 軟刪除該任務（保留資料以便還原）
+		_, error = database.Exec("UPDATE tasks SET deleted_at = ? WHERE id = ?", time.Now().UTC(), identifier)
 		if error != nil {
 			log.Printf("❌ Failed to delete task %s: %v", identifier, error)
 			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
@@ -182,7 +740,7 @@ func DeleteTask(database *sql.DB) gin.HandlerFunc {
 			JOIN (
 				SELECT id, ROW_NUMBER() OVER (ORDER BY sort_order) AS new_sort
 				FROM tasks
-				WHERE section_id = ?
+				WHERE section_id = ? AND deleted_at IS NULL
 			) sorted
 			ON t.id = sorted.id
 			SET t.sort_order = sorted.new_sort;
@@ -197,3 +755,671 @@ func DeleteTask(database *sql.DB) gin.HandlerFunc {
 		context.JSON(http.StatusOK, gin.H{"message": "Task deleted and reordered"})
 	}
 }
+
+// RestoreTask godoc
+// @Summary      還原已刪除的任務
+// @Description  將垃圾桶中的任務還原，並放到所屬區塊的最後；區塊已關閉時回傳 409
+// @ID           restoreTask
+// @Tags         Plans
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "任務 ID"
+// @Success      200  {object}  models.Task
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/tasks/{id}/restore [post]
+func RestoreTask(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+			return
+		}
+		userIdentifier := context.GetInt64("user_id")
+
+		transaction, error := database.Begin()
+		if error != nil {
+			log.Printf("❌ Failed to begin transaction: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "DB transaction error"})
+			return
+		}
+		defer transaction.Rollback()
+
+		// ✅ 只有已刪除的任務可以還原，並鎖定所屬 section 以取得新的 sort_order
+		var sectionIdentifier int64
+		var taskOwnerIdentifier int64
+		var isClosed bool
+		error = transaction.QueryRow(`
+			SELECT s.id, s.user_id, s.is_closed
+			FROM tasks t
+			JOIN sections s ON t.section_id = s.id
+			WHERE t.id = ? AND t.deleted_at IS NOT NULL
+			FOR UPDATE`, identifier).Scan(&sectionIdentifier, &taskOwnerIdentifier, &isClosed)
+		if error == sql.ErrNoRows {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Deleted task not found"})
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to query deleted task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore task"})
+			return
+		}
+		if taskOwnerIdentifier != userIdentifier {
+			log.Printf("❌ Unauthorized to restore task ID=%d by user_id=%d", identifier, userIdentifier)
+			context.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to restore this task"})
+			return
+		}
+		if isClosed {
+			context.JSON(http.StatusConflict, gin.H{"error": "Section is closed"})
+			return
+		}
+
+		var maxSort sql.NullInt64
+		if error := transaction.QueryRow("SELECT MAX(sort_order) FROM tasks WHERE section_id = ? AND deleted_at IS NULL", sectionIdentifier).Scan(&maxSort); error != nil {
+			log.Printf("❌ Failed to get max sort: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get max sort"})
+			return
+		}
+
+		// 垃圾桶中的任務在其他任務使用同一個 external_ref 時已被清除，還原不會產生重複
+		_, error = transaction.Exec(
+			"UPDATE tasks SET deleted_at = NULL, sort_order = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+			int(maxSort.Int64)+1, identifier,
+		)
+		if error != nil {
+			log.Printf("❌ Failed to restore task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore task"})
+			return
+		}
+
+		task, error := scanTask(transaction.QueryRow("SELECT "+taskColumns+" FROM tasks t WHERE t.id = ?", identifier))
+		if error != nil {
+			log.Printf("❌ Failed to read restored task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore task"})
+			return
+		}
+
+		if error := transaction.Commit(); error != nil {
+			log.Printf("❌ Commit failed: %v", error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+			return
+		}
+
+		log.Printf("✅ Task restored: ID=%d, SectionID=%d", identifier, sectionIdentifier)
+		context.JSON(http.StatusOK, task)
+	}
+}
+
+// trashConditions 回傳本人垃圾桶中（仍在保留期限內、可還原）任務的 WHERE 條件
+func trashConditions(userIdentifier int64) (string, []interface{}) {
+	cutoff := time.Now().UTC().Add(-models.TaskTrashRetention)
+	return "t.user_id = ? AND t.deleted_at IS NOT NULL AND t.deleted_at >= ?", []interface{}{userIdentifier, cutoff}
+}
+
+// GetTrashedTasks godoc
+// @Summary      取得垃圾桶中的任務
+// @Description  回傳本人已刪除、仍在 30 天保留期限內可還原的任務，依刪除時間由新到舊排序，支援分頁
+// @ID           listTrashedTasks
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query  int  false  "頁碼（從 1 開始）"
+// @Param        page_size  query  int  false  "每頁筆數（預設 50，最多 200）"
+// @Success      200  {object}  models.TaskPage
+// @Header       200  {integer}  X-Total-Count  "任務總數"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/tasks/trash [get]
+func GetTrashedTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		page, pageSize, error := parsePagination(context)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": error.Error()})
+			return
+		}
+
+		conditions, conditionArgs := trashConditions(userIdentifier)
+
+		var total int
+		error = database.QueryRowContext(context.Request.Context(), "SELECT COUNT(*) FROM tasks t WHERE "+conditions, conditionArgs...).Scan(&total)
+		if error != nil {
+			log.Printf("❌ Failed to count trashed tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+
+		rows, error := database.QueryContext(context.Request.Context(), `
+			SELECT `+taskColumns+`
+			FROM tasks t
+			WHERE `+conditions+`
+			ORDER BY t.deleted_at DESC, t.id DESC
+			LIMIT ? OFFSET ?`,
+			append(conditionArgs, pageSize, (page-1)*pageSize)...,
+		)
+		if error != nil {
+			log.Printf("❌ Failed to query trashed tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+		defer rows.Close()
+
+		tasks := []models.Task{}
+		for rows.Next() {
+			task, error := scanTask(rows)
+			if error != nil {
+				log.Printf("❌ Failed to scan task: %v", error)
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to read trashed tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+
+		context.Header("X-Total-Count", strconv.Itoa(total))
+		context.JSON(http.StatusOK, models.TaskPage{Items: tasks, Page: page, PageSize: pageSize, Total: total})
+	}
+}
+
+// CountTrashedTasks godoc
+// @Summary      取得垃圾桶任務數量（HEAD）
+// @Description  與 GET /plans/tasks/trash 相同的條件，只以 COUNT(*) 計算並透過 X-Total-Count header 回傳，不含內容
+// @ID           countTrashedTasks
+// @Tags         Plans
+// @Security     BearerAuth
+// @Success      200  {string}  string  "X-Total-Count header"
+// @Header       200  {integer}  X-Total-Count  "任務總數"
+// @Failure      500
+// @Router       /plans/tasks/trash [head]
+func CountTrashedTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		conditions, conditionArgs := trashConditions(userIdentifier)
+
+		var total int
+		error := database.QueryRowContext(context.Request.Context(), "SELECT COUNT(*) FROM tasks t WHERE "+conditions, conditionArgs...).Scan(&total)
+		if error != nil {
+			log.Printf("❌ Failed to count trashed tasks: %v", error)
+			context.Status(serverErrorStatus(context))
+			return
+		}
+
+		context.Header("X-Total-Count", strconv.Itoa(total))
+		context.Status(http.StatusOK)
+	}
+}
+
+const (
+	defaultUpcomingWindow = 7 * 24 * time.Hour
+	maxUpcomingWindow     = 365 * 24 * time.Hour
+)
+
+// GetUpcomingTasks godoc
+// @Summary      取得即將到期的任務
+// @Description  回傳本人在 within 時間內到期、尚未完成的任務，依到期日由近到遠排序；沒有到期日的任務不會出現。
+// @Description  篩選規則與 GET /plans/tasks/today 相同：預設排除封存區塊中的任務與延後中的任務
+// @ID           listUpcomingTasks
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        within  query  string  false  "時間範圍，例如 7d、36h（預設 7d，最多 365d）"
+// @Param        section_ids       query  string  false  "只包含這些區塊 ID，逗號分隔（最多 100 個）"
+// @Param        label             query  string  false  "只回傳帶有此標籤的任務"
+// @Param        include_deferred  query  bool    false  "包含仍在延後中的任務（預設不包含）"
+// @Param        include_archived  query  bool    false  "未帶 section_ids 時包含封存區塊中的任務（預設不包含）"
+// @Success      200  {array}   models.Task
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/tasks/upcoming [get]
+func GetUpcomingTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		within := defaultUpcomingWindow
+		if raw := context.Query("within"); raw != "" {
+			parsed, error := parseWindow(raw)
+			if error != nil || parsed <= 0 || parsed > maxUpcomingWindow {
+				context.JSON(http.StatusBadRequest, gin.H{"error": "within must be a positive duration up to 365d (e.g. 7d, 36h)"})
+				return
+			}
+			within = parsed
+		}
+
+		conditions, conditionArgs, isValid := taskListConditions(context, database, userIdentifier, false)
+		if !isValid {
+			return
+		}
+
+		now := time.Now().UTC()
+		rows, error := database.QueryContext(context.Request.Context(), `
+			SELECT `+taskColumns+`
+			FROM tasks t
+			WHERE `+conditions+` AND t.is_completed = FALSE
+			  AND t.due_date IS NOT NULL AND t.due_date >= ? AND t.due_date <= ?
+			ORDER BY t.due_date ASC, t.id ASC`, append(conditionArgs, now, now.Add(within))...)
+		if error != nil {
+			log.Printf("❌ Failed to query upcoming tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+		defer rows.Close()
+
+		tasks := []models.Task{}
+		for rows.Next() {
+			task, error := scanTask(rows)
+			if error != nil {
+				log.Printf("❌ Failed to scan task: %v", error)
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to read upcoming tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+
+		context.JSON(http.StatusOK, tasks)
+	}
+}
+
+// GetTodayTasks godoc
+// @Summary      取得今天到期的任務
+// @Description  回傳本人今天（依 tz 時區的日曆日）到期、尚未完成的任務，附上所屬區塊標題；依到期時間排序，同時間再依優先度由高到低。
+// @Description  篩選規則與 GET /plans/tasks/upcoming 相同：預設排除封存區塊中的任務與延後中的任務
+// @ID           listTodayTasks
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tz   query     string  false  "IANA 時區，例如 Asia/Taipei（預設 UTC）"
+// @Param        section_ids       query  string  false  "只包含這些區塊 ID，逗號分隔（最多 100 個）"
+// @Param        label             query  string  false  "只回傳帶有此標籤的任務"
+// @Param        include_deferred  query  bool    false  "包含仍在延後中的任務（預設不包含）"
+// @Param        include_archived  query  bool    false  "未帶 section_ids 時包含封存區塊中的任務（預設不包含）"
+// @Success      200  {array}   models.TaskSearchResult
+// @Failure      400  {object}  models.APIError
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/tasks/today [get]
+func GetTodayTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		location := time.UTC
+		if raw := context.Query("tz"); raw != "" {
+			loaded, error := time.LoadLocation(raw)
+			if error != nil {
+				RespondError(context, http.StatusBadRequest, CodeInvalidInput, "tz must be a valid IANA time zone (e.g. Asia/Taipei)")
+				return
+			}
+			location = loaded
+		}
+
+		now := time.Now().In(location)
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+		endOfDay := startOfDay.AddDate(0, 0, 1)
+
+		conditions, conditionArgs, isValid := taskListConditions(context, database, userIdentifier, false)
+		if !isValid {
+			return
+		}
+
+		rows, error := database.QueryContext(context.Request.Context(), `
+			SELECT `+taskColumns+`, s.title
+			FROM tasks t
+			JOIN sections s ON s.id = t.section_id
+			WHERE `+conditions+` AND t.is_completed = FALSE
+			  AND t.due_date >= ? AND t.due_date < ?
+			ORDER BY t.due_date ASC, FIELD(t.priority, 'high', 'medium', 'low'), t.id ASC`, append(conditionArgs, startOfDay.UTC(), endOfDay.UTC())...)
+		if error != nil {
+			log.Printf("❌ Failed to query today's tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+		defer rows.Close()
+
+		results := []models.TaskSearchResult{}
+		for rows.Next() {
+			var sectionTitle string
+			task, error := scanTask(rows, &sectionTitle)
+			if error != nil {
+				log.Printf("❌ Failed to scan task: %v", error)
+				continue
+			}
+			results = append(results, models.TaskSearchResult{Task: task, SectionTitle: sectionTitle})
+		}
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to read today's tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+
+		context.JSON(http.StatusOK, results)
+	}
+}
+
+// GetNextTask godoc
+// @Summary      取得下一個要做的任務（專注模式）
+// @Description  回傳本人單一個最該先做的未完成任務：優先度由高到低，再依到期日由近到遠（沒有到期日的排最後），最後依排序；
+// @Description  篩選規則與 GET /plans/tasks/today 相同，預設排除封存區塊中的任務與延後中的任務。沒有待辦任務時回傳 204
+// @ID           getNextTask
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        section_ids       query  string  false  "只包含這些區塊 ID，逗號分隔（最多 100 個）"
+// @Param        label             query  string  false  "只考慮帶有此標籤的任務"
+// @Param        include_deferred  query  bool    false  "包含仍在延後中的任務（預設不包含）"
+// @Param        include_archived  query  bool    false  "未帶 section_ids 時包含封存區塊中的任務（預設不包含）"
+// @Success      200  {object}  models.Task
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/next [get]
+func GetNextTask(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		conditions, conditionArgs, isValid := taskListConditions(context, database, userIdentifier, false)
+		if !isValid {
+			return
+		}
+
+		task, error := scanTask(database.QueryRowContext(context.Request.Context(), `
+			SELECT `+taskColumns+`
+			FROM tasks t
+			WHERE `+conditions+` AND t.is_completed = FALSE
+			ORDER BY FIELD(t.priority, 'high', 'medium', 'low'), t.due_date IS NULL, t.due_date ASC, t.sort_order ASC, t.id ASC
+			LIMIT 1`, conditionArgs...))
+		if error == sql.ErrNoRows {
+			context.Status(http.StatusNoContent)
+			return
+		}
+		if error != nil {
+			log.Printf("❌ Failed to query next task: %v", error)
+			respondServerError(context, "Failed to fetch next task")
+			return
+		}
+
+		context.JSON(http.StatusOK, task)
+	}
+}
+
+// parseWindow 解析時間範圍，除了 Go duration（例如 36h）之外也接受以天為單位的 Nd
+func parseWindow(raw string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(raw, "d"); found {
+		count, error := strconv.Atoi(days)
+		if error != nil {
+			return 0, error
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(raw)
+}
+
+const maxSectionIdentifiers = 100
+
+// taskListConditions 解析 section_ids、label、include_deferred 與 include_archived 並確認所有 section 都屬於該 user，
+// 回傳 tasks t 的 WHERE 條件；失敗時已寫好錯誤回應並回傳 false。
+// 沒帶 section_ids 時（需帶 label，或 sectionsRequired 為 false）會查詢使用者所有區塊，預設排除已封存的區塊。
+func taskListConditions(context *gin.Context, database *sql.DB, userIdentifier int64, sectionsRequired bool) (string, []interface{}, bool) {
+	includeDeferred, error := parseIncludeDeferred(context)
+	if error != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": error.Error()})
+		return "", nil, false
+	}
+
+	label := strings.TrimSpace(context.Query("label"))
+	if context.Query("section_ids") == "" && (label != "" || !sectionsRequired) {
+		includeArchived, error := parseIncludeArchived(context)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": error.Error()})
+			return "", nil, false
+		}
+
+		conditions := "t.user_id = ? AND t.deleted_at IS NULL"
+		args := []interface{}{userIdentifier}
+		if !includeArchived {
+			conditions += " AND " + notArchivedSectionCondition
+		}
+		if label != "" {
+			conditions += " AND " + labelCondition
+			args = append(args, label)
+		}
+		if !includeDeferred {
+			condition, deferredArgs := notDeferredCondition()
+			conditions += " AND " + condition
+			args = append(args, deferredArgs...)
+		}
+		return conditions, args, true
+	}
+
+	sectionIdentifiers, error := parseIdentifierList(context.Query("section_ids"))
+	if error != nil || len(sectionIdentifiers) == 0 || len(sectionIdentifiers) > maxSectionIdentifiers {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "section_ids must be 1 to 100 comma-separated IDs"})
+		return "", nil, false
+	}
+
+	placeholders, args := buildInClause(sectionIdentifiers)
+
+	// ✅ 單一查詢確認所有 section 都屬於該 user
+	var ownedCount int
+	error = database.QueryRowContext(context.Request.Context(),
+		"SELECT COUNT(*) FROM sections WHERE user_id = ? AND id IN ("+placeholders+")",
+		append([]interface{}{userIdentifier}, args...)...,
+	).Scan(&ownedCount)
+	if error != nil {
+		log.Printf("❌ Failed to verify section ownership: %v", error)
+		respondServerError(context, "Failed to fetch tasks")
+		return "", nil, false
+	}
+	if ownedCount != len(sectionIdentifiers) {
+		log.Printf("❌ Unauthorized section in %v for user_id=%d", sectionIdentifiers, userIdentifier)
+		context.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to access one or more sections"})
+		return "", nil, false
+	}
+
+	conditions := "t.section_id IN (" + placeholders + ") AND t.deleted_at IS NULL"
+	if label != "" {
+		conditions += " AND " + labelCondition
+		args = append(args, label)
+	}
+	if !includeDeferred {
+		condition, deferredArgs := notDeferredCondition()
+		conditions += " AND " + condition
+		args = append(args, deferredArgs...)
+	}
+	return conditions, args, true
+}
+
+// labelCondition 篩選帶有指定標籤的任務
+const labelCondition = "EXISTS (SELECT 1 FROM task_labels tl WHERE tl.task_id = t.id AND tl.label = ?)"
+
+// notArchivedSectionCondition 排除位於已封存區塊中的任務，不依賴查詢是否 JOIN sections
+const notArchivedSectionCondition = "NOT EXISTS (SELECT 1 FROM sections sa WHERE sa.id = t.section_id AND sa.archived = TRUE)"
+
+// CountTasks godoc
+// @Summary      取得任務數量（HEAD）
+// @Description  與 GET /plans/tasks 相同的條件，只以 COUNT(*) 計算並透過 X-Total-Count header 回傳，不含內容
+// @ID           countTasks
+// @Tags         Plans
+// @Security     BearerAuth
+// @Param        section_ids       query  string  false  "區塊 ID，逗號分隔（最多 100 個，未帶 label 時必填）"
+// @Param        label             query  string  false  "只計算帶有此標籤的任務"
+// @Param        include_deferred  query  bool    false  "包含仍在延後中的任務（預設不包含）"
+// @Param        include_archived  query  bool    false  "只帶 label 時包含封存區塊中的任務（預設不包含）"
+// @Success      200  {string}  string  "X-Total-Count header"
+// @Header       200  {integer}  X-Total-Count  "任務總數"
+// @Failure      400,403,500
+// @Router       /plans/tasks [head]
+func CountTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		conditions, conditionArgs, isValid := taskListConditions(context, database, userIdentifier, true)
+		if !isValid {
+			return
+		}
+
+		var total int
+		error := database.QueryRowContext(context.Request.Context(), "SELECT COUNT(*) FROM tasks t WHERE "+conditions, conditionArgs...).Scan(&total)
+		if error != nil {
+			log.Printf("❌ Failed to count tasks: %v", error)
+			context.Status(serverErrorStatus(context))
+			return
+		}
+
+		context.Header("X-Total-Count", strconv.Itoa(total))
+		context.Status(http.StatusOK)
+	}
+}
+
+// GetTasks godoc
+// @Summary      依多個區塊取得任務
+// @Description  回傳指定區塊（section_ids，逗號分隔）內的任務，支援分頁；group=section 時依區塊分組。任一區塊不屬於本人即回傳 403。
+// @Description  帶 label 時只回傳有該標籤的任務；只帶 label 不帶 section_ids 時查詢本人所有區塊
+// @ID           listTasks
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        section_ids  query  string  false  "區塊 ID，逗號分隔（最多 100 個，未帶 label 時必填）"
+// @Param        label        query  string  false  "只回傳帶有此標籤的任務"
+// @Param        group        query  string  false  "分組方式：flat（預設）或 section"
+// @Param        fields       query  string  false  "只回傳的任務欄位，逗號分隔（例如 id,title,is_completed）"
+// @Param        page         query  int     false  "頁碼（從 1 開始）"
+// @Param        page_size    query  int     false  "每頁筆數（預設 50，最多 200）"
+// @Param        include_deferred  query  bool  false  "包含仍在延後中的任務（預設不包含）"
+// @Param        include_archived  query  bool  false  "只帶 label 時包含封存區塊中的任務（預設不包含）"
+// @Success      200  {object}  models.TaskPage
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /plans/tasks [get]
+func GetTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		group := context.DefaultQuery("group", "flat")
+		if group != "flat" && group != "section" {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "group must be flat or section"})
+			return
+		}
+
+		page, pageSize, error := parsePagination(context)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": error.Error()})
+			return
+		}
+
+		fields, error := parseFields(context.Query("fields"), taskFieldAllowlist)
+		if error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": error.Error()})
+			return
+		}
+
+		conditions, conditionArgs, isValid := taskListConditions(context, database, userIdentifier, true)
+		if !isValid {
+			return
+		}
+
+		var total int
+		error = database.QueryRowContext(context.Request.Context(), "SELECT COUNT(*) FROM tasks t WHERE "+conditions, conditionArgs...).Scan(&total)
+		if error != nil {
+			log.Printf("❌ Failed to count tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+
+		rows, error := database.QueryContext(context.Request.Context(), `
+			SELECT `+taskColumns+`
+			FROM tasks t
+			JOIN sections s ON t.section_id = s.id
+			WHERE `+conditions+`
+			ORDER BY s.sort_order ASC, t.sort_order ASC
+			LIMIT ? OFFSET ?`,
+			append(conditionArgs, pageSize, (page-1)*pageSize)...,
+		)
+		if error != nil {
+			log.Printf("❌ Failed to query tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+		defer rows.Close()
+
+		tasks := []models.Task{}
+		for rows.Next() {
+			task, error := scanTask(rows)
+			if error != nil {
+				log.Printf("❌ Failed to scan task: %v", error)
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to read tasks: %v", error)
+			respondServerError(context, "Failed to fetch tasks")
+			return
+		}
+
+		response := models.TaskPage{Page: page, PageSize: pageSize, Total: total}
+		if group == "section" {
+			groups := []gin.H{}
+			var groupTasks []models.Task
+			for index, task := range tasks {
+				groupTasks = append(groupTasks, task)
+				if index == len(tasks)-1 || tasks[index+1].SectionID != task.SectionID {
+					items, error := pickFields(groupTasks, fields)
+					if error != nil {
+						log.Printf("❌ Failed to select fields: %v", error)
+						respondServerError(context, "Failed to fetch tasks")
+						return
+					}
+					groups = append(groups, gin.H{"section_id": task.SectionID, "tasks": items})
+					groupTasks = nil
+				}
+			}
+			response.Items = groups
+		} else {
+			items, error := pickFields(tasks, fields)
+			if error != nil {
+				log.Printf("❌ Failed to select fields: %v", error)
+				respondServerError(context, "Failed to fetch tasks")
+				return
+			}
+			response.Items = items
+		}
+
+		context.Header("X-Total-Count", strconv.Itoa(total))
+		context.JSON(http.StatusOK, response)
+	}
+}
+
+// parseIdentifierList 解析逗號分隔的 ID 清單並去除重複
+func parseIdentifierList(raw string) ([]int64, error) {
+	var identifiers []int64
+	seen := make(map[int64]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		identifier, error := strconv.ParseInt(part, 10, 64)
+		if error != nil || identifier < 1 {
+			return nil, fmt.Errorf("invalid ID %q", part)
+		}
+		if !seen[identifier] {
+			seen[identifier] = true
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	return identifiers, nil
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportTasksCSVRoundTrips(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/plans/tasks/export.csv", withUser(1), ExportTasksCSV(database))
+
+	mock.ExpectQuery("SELECT s.title, t.title, t.content, t.is_completed, t.sort_order, t.created_at").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"section_title", "title", "content", "is_completed", "sort_order", "created_at"}).
+			AddRow("Inbox", "Buy milk, eggs", "line one\nline \"two\"", true, 1, "2026-01-05 09:00:00").
+			AddRow("Inbox", "Call mom", "", false, 2, "2026-01-06 10:00:00"))
+
+	recorder := performRequest(router, http.MethodGet, "/plans/tasks/export.csv", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Fatalf("expected text/csv, got %q", contentType)
+	}
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("response is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d records", len(records))
+	}
+	if !reflect.DeepEqual(records[0], taskCSVHeader) {
+		t.Fatalf("unexpected header row: %v", records[0])
+	}
+	// 逗號、換行與引號都要原樣還原
+	want := []string{"Inbox", "Buy milk, eggs", "line one\nline \"two\"", "true", "1", "2026-01-05 09:00:00"}
+	if !reflect.DeepEqual(records[1], want) {
+		t.Fatalf("expected %q, got %q", want, records[1])
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Walter1412/micro-backend/config"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AttachTaskLabel godoc
+// @Summary      為任務加上標籤
+// @Description  為本人的任務加上自訂標籤，同一個標籤重複加上不會產生第二筆；回傳任務目前所有標籤。
+// @Description  開啟 NORMALIZE_LABELS 時標籤會轉為小寫，"Work" 與 "work" 視為同一個標籤
+// @ID           attachTaskLabel
+// @Tags         Plans
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int                    true  "任務 ID"
+// @Param        body  body  models.TaskLabelInput  true  "標籤"
+// @Success      200   {object}  models.TaskLabels
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /plans/tasks/{id}/labels [post]
+func AttachTaskLabel(database *sql.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, isValid := ownedTaskIdentifier(context, database)
+		if !isValid {
+			return
+		}
+
+		var input models.TaskLabelInput
+		if error := context.ShouldBindJSON(&input); error != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		label := taskLabelFromInput(input.Label, cfg)
+		if label == "" {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "label is required"})
+			return
+		}
+
+		if error := models.AttachTaskLabel(database, identifier, label); error != nil {
+			log.Printf("❌ Failed to attach label to task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to attach label"})
+			return
+		}
+
+		labels, error := models.GetTaskLabels(database, identifier)
+		if error != nil {
+			log.Printf("❌ Failed to query labels of task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to attach label"})
+			return
+		}
+
+		context.JSON(http.StatusOK, models.TaskLabels{TaskID: identifier, Labels: labels})
+	}
+}
+
+// DetachTaskLabel godoc
+// @Summary      移除任務的標籤
+// @Description  移除本人任務上的指定標籤，回傳任務剩下的標籤；開啟 NORMALIZE_LABELS 時不區分大小寫
+// @ID           detachTaskLabel
+// @Tags         Plans
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id     path  int     true  "任務 ID"
+// @Param        label  path  string  true  "標籤"
+// @Success      200    {object}  models.TaskLabels
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /plans/tasks/{id}/labels/{label} [delete]
+func DetachTaskLabel(database *sql.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		identifier, isValid := ownedTaskIdentifier(context, database)
+		if !isValid {
+			return
+		}
+
+		removed, error := models.DetachTaskLabel(database, identifier, taskLabelFromInput(context.Param("label"), cfg))
+		if error != nil {
+			log.Printf("❌ Failed to detach label from task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detach label"})
+			return
+		}
+		if !removed {
+			context.JSON(http.StatusNotFound, gin.H{"error": "Label not found on task"})
+			return
+		}
+
+		labels, error := models.GetTaskLabels(database, identifier)
+		if error != nil {
+			log.Printf("❌ Failed to query labels of task %d: %v", identifier, error)
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detach label"})
+			return
+		}
+
+		context.JSON(http.StatusOK, models.TaskLabels{TaskID: identifier, Labels: labels})
+	}
+}
+
+// taskLabelFromInput 去除標籤前後空白，開啟 NormalizeLabels 時另外轉為小寫
+func taskLabelFromInput(label string, cfg *config.Config) string {
+	if cfg.Server.NormalizeLabels {
+		return models.NormalizeTaskLabel(label)
+	}
+	return strings.TrimSpace(label)
+}
+
+// ownedTaskIdentifier 透過 join sections 確認 :id 的任務存在（未刪除）且屬於目前使用者；
+// 失敗時已寫好錯誤回應並回傳 false
+func ownedTaskIdentifier(context *gin.Context, database *sql.DB) (int64, bool) {
+	identifier, error := strconv.ParseInt(context.Param("id"), 10, 64)
+	if error != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return 0, false
+	}
+	userIdentifier := context.GetInt64("user_id")
+
+	var taskOwnerIdentifier int64
+	error = database.QueryRow(`
+		SELECT s.user_id
+		FROM tasks t
+		JOIN sections s ON t.section_id = s.id
+		WHERE t.id = ? AND t.deleted_at IS NULL`, identifier).Scan(&taskOwnerIdentifier)
+	if error == sql.ErrNoRows {
+		context.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return 0, false
+	}
+	if error != nil {
+		log.Printf("❌ Failed to query task owner: %v", error)
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query task"})
+		return 0, false
+	}
+	if taskOwnerIdentifier != userIdentifier {
+		log.Printf("❌ Unauthorized to access task ID=%d by user_id=%d", identifier, userIdentifier)
+		context.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to access this task"})
+		return 0, false
+	}
+	return identifier, true
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func newTaskLabelRouter(t *testing.T, normalize bool) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	cfg := testConfig()
+	cfg.Server.NormalizeLabels = normalize
+
+	router := gin.New()
+	router.POST("/plans/tasks/:id/labels", withUser(1), AttachTaskLabel(database, cfg))
+	router.DELETE("/plans/tasks/:id/labels/:label", withUser(1), DetachTaskLabel(database, cfg))
+	mock.ExpectQuery("SELECT s.user_id\\s+FROM tasks t\\s+JOIN sections s ON t.section_id = s.id\\s+WHERE t.id = \\? AND t.deleted_at IS NULL").
+		WithArgs(int64(5)).WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	return router, mock
+}
+
+func TestAttachTaskLabelLowercasesWhenNormalizing(t *testing.T) {
+	router, mock := newTaskLabelRouter(t, true)
+
+	// "Work" 與既有的 "work" 是同一個標籤，INSERT IGNORE 不會產生第二筆
+	mock.ExpectExec("INSERT IGNORE INTO task_labels").WithArgs(int64(5), "work").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT label FROM task_labels WHERE task_id = \\?").WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"label"}).AddRow("work"))
+
+	recorder := performRequest(router, http.MethodPost, "/plans/tasks/5/labels", `{"label":"  Work "}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestAttachTaskLabelKeepsCaseByDefault(t *testing.T) {
+	router, mock := newTaskLabelRouter(t, false)
+
+	mock.ExpectExec("INSERT IGNORE INTO task_labels").WithArgs(int64(5), "Work").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT label FROM task_labels WHERE task_id = \\?").WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"label"}).AddRow("Work"))
+
+	recorder := performRequest(router, http.MethodPost, "/plans/tasks/5/labels", `{"label":"  Work "}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestDetachTaskLabelLowercasesWhenNormalizing(t *testing.T) {
+	router, mock := newTaskLabelRouter(t, true)
+
+	mock.ExpectExec("DELETE FROM task_labels WHERE task_id = \\? AND label = \\?").WithArgs(int64(5), "work").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT label FROM task_labels WHERE task_id = \\?").WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"label"}))
+
+	recorder := performRequest(router, http.MethodDelete, "/plans/tasks/5/labels/Work", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
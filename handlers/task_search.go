@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	minSearchQueryLength = 2
+	maxSearchResults     = 100
+)
+
+// likeEscaper 跳脫 LIKE 的萬用字元，讓關鍵字中的 % 與 _ 以字面比對
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchTasks godoc
+// @Summary      搜尋任務
+// @Description  以關鍵字搜尋本人的任務標題與內容（不分大小寫的部分比對），回傳時附上所屬區塊標題；已刪除的任務與封存區塊中的任務不會出現。依更新時間由新到舊排序，最多 100 筆
+// @ID           searchTasks
+// @Tags         Plans
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q    query     string  true  "關鍵字（至少 2 個字元）"
+// @Success      200  {array}   models.TaskSearchResult
+// @Failure      400  {object}  models.APIError
+// @Failure      500  {object}  models.APIError
+// @Router       /plans/search [get]
+func SearchTasks(database *sql.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+
+		query := strings.TrimSpace(context.Query("q"))
+		if utf8.RuneCountInString(query) < minSearchQueryLength {
+			RespondError(context, http.StatusBadRequest, CodeInvalidInput, "q must be at least 2 characters")
+			return
+		}
+		pattern := "%" + likeEscaper.Replace(query) + "%"
+
+		rows, error := database.QueryContext(context.Request.Context(), `
+			SELECT `+taskColumns+`, s.title
+			FROM tasks t
+			JOIN sections s ON s.id = t.section_id
+			WHERE t.user_id = ? AND t.deleted_at IS NULL AND s.archived = FALSE
+			  AND (t.title LIKE ? OR t.content LIKE ?)
+			ORDER BY t.updated_at DESC, t.id DESC
+			LIMIT ?`, userIdentifier, pattern, pattern, maxSearchResults)
+		if error != nil {
+			log.Printf("❌ Failed to search tasks: %v", error)
+			respondServerError(context, "Failed to search tasks")
+			return
+		}
+		defer rows.Close()
+
+		results := []models.TaskSearchResult{}
+		for rows.Next() {
+			var sectionTitle string
+			task, error := scanTask(rows, &sectionTitle)
+			if error != nil {
+				log.Printf("❌ Failed to scan task: %v", error)
+				continue
+			}
+			results = append(results, models.TaskSearchResult{Task: task, SectionTitle: sectionTitle})
+		}
+		if error := rows.Err(); error != nil {
+			log.Printf("❌ Failed to read search results: %v", error)
+			respondServerError(context, "Failed to search tasks")
+			return
+		}
+
+		context.JSON(http.StatusOK, results)
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func newUpdateTaskRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.PUT("/plans/tasks/:id", withUser(1), UpdateTask(database))
+	mock.ExpectQuery("SELECT user_id FROM tasks WHERE id = \\? AND deleted_at IS NULL").WithArgs("5").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	return router, mock
+}
+
+func TestUpdateTaskTitleOnly(t *testing.T) {
+	router, mock := newUpdateTaskRouter(t)
+
+	// 只更新 title，其他欄位不能出現在 UPDATE 中
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET title = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?")).
+		WithArgs("New title", "5").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	recorder := performRequest(router, http.MethodPut, "/plans/tasks/5", `{"title":"New title"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestUpdateTaskCompletionOnly(t *testing.T) {
+	router, mock := newUpdateTaskRouter(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE tasks SET is_completed = ?, completed_at = CASE WHEN ? THEN COALESCE(completed_at, CURRENT_TIMESTAMP) ELSE NULL END, updated_at = CURRENT_TIMESTAMP WHERE id = ?")).
+		WithArgs(true, true, "5").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	recorder := performRequest(router, http.MethodPut, "/plans/tasks/5", `{"is_completed":true}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestUpdateTaskWithoutFieldsIsRejected(t *testing.T) {
+	database, _ := newMockDB(t)
+	router := gin.New()
+	router.PUT("/plans/tasks/:id", withUser(1), UpdateTask(database))
+
+	recorder := performRequest(router, http.MethodPut, "/plans/tasks/5", `{}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestGetTrashedTasksListsOnlyRestorableTasks(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/plans/tasks/trash", withUser(1), GetTrashedTasks(database))
+
+	deletedAt := time.Now().UTC().Add(-time.Hour)
+	columns := []string{"id", "section_id", "content", "is_completed", "priority", "sort_order", "version", "created_at", "updated_at", "title", "external_ref", "due_date", "deferred_until", "deleted_at"}
+
+	// 只列出本人、已刪除且仍在保留期限內的任務
+	condition := regexp.QuoteMeta("WHERE t.user_id = ? AND t.deleted_at IS NOT NULL AND t.deleted_at >= ?")
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM tasks t "+condition).WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(condition+"\\s+ORDER BY t.deleted_at DESC, t.id DESC\\s+LIMIT \\? OFFSET \\?").WithArgs(1, sqlmock.AnyArg(), 50, 0).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(5, 2, "", false, "medium", 1, 1, deletedAt, deletedAt, "Old task", nil, nil, nil, deletedAt))
+
+	recorder := performRequest(router, http.MethodGet, "/plans/tasks/trash", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("X-Total-Count"); got != "1" {
+		t.Fatalf("expected X-Total-Count 1, got %q", got)
+	}
+
+	var page struct {
+		Items []models.Task `json:"items"`
+		Total int           `json:"total"`
+	}
+	if error := json.Unmarshal(recorder.Body.Bytes(), &page); error != nil {
+		t.Fatalf("failed to decode response: %v", error)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].ID != 5 || page.Items[0].DeletedAt == nil {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
+
+func TestCountTrashedTasksRespondsWithHeaderOnly(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.HEAD("/plans/tasks/trash", withUser(1), CountTrashedTasks(database))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM tasks t WHERE t.user_id = ? AND t.deleted_at IS NOT NULL AND t.deleted_at >= ?")).
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	recorder := performRequest(router, http.MethodHead, "/plans/tasks/trash", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", recorder.Code)
+	}
+	if got := recorder.Header().Get("X-Total-Count"); got != "3" {
+		t.Fatalf("expected X-Total-Count 3, got %q", got)
+	}
+	if recorder.Body.Len() != 0 {
+		t.Fatalf("expected empty body, got %q", recorder.Body.String())
+	}
+}
+
+func TestTodayAndUpcomingShareArchivedAndDeferredRules(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/plans/tasks/upcoming", withUser(1), GetUpcomingTasks(database))
+	router.GET("/plans/tasks/today", withUser(1), GetTodayTasks(database))
+
+	// 兩個清單都要排除封存區塊中的任務與延後中的任務
+	shared := regexp.QuoteMeta("WHERE t.user_id = ? AND t.deleted_at IS NULL AND " + notArchivedSectionCondition + " AND (t.deferred_until IS NULL OR t.deferred_until <= ?) AND t.is_completed = FALSE")
+	mock.ExpectQuery(shared).WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery(shared).WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	for _, path := range []string{"/plans/tasks/upcoming", "/plans/tasks/today"} {
+		recorder := performRequest(router, http.MethodGet, path, "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestGetNextTaskReturnsSingleOrderedTask(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/plans/next", withUser(1), GetNextTask(database))
+
+	now := time.Now().UTC()
+	columns := []string{"id", "section_id", "content", "is_completed", "priority", "sort_order", "version", "created_at", "updated_at", "title", "external_ref", "due_date", "deferred_until", "deleted_at"}
+	// 優先度由高到低，再依到期日（NULL 排最後）與排序，只取一筆
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY FIELD(t.priority, 'high', 'medium', 'low'), t.due_date IS NULL, t.due_date ASC, t.sort_order ASC, t.id ASC")+"\\s+LIMIT 1").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(5, 2, "", false, "high", 3, 1, now, now, "Ship it", nil, now, nil, nil))
+
+	recorder := performRequest(router, http.MethodGet, "/plans/next", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var task models.Task
+	if error := json.Unmarshal(recorder.Body.Bytes(), &task); error != nil {
+		t.Fatalf("failed to decode response: %v", error)
+	}
+	if task.ID != 5 {
+		t.Fatalf("expected task 5, got %+v", task)
+	}
+}
+
+func TestGetNextTaskReturnsNoContentWhenNothingPending(t *testing.T) {
+	database, mock := newMockDB(t)
+	router := gin.New()
+	router.GET("/plans/next", withUser(1), GetNextTask(database))
+
+	mock.ExpectQuery("LIMIT 1").WithArgs(1, sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows(nil))
+
+	recorder := performRequest(router, http.MethodGet, "/plans/next", "")
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// 驗證錯誤使用 JSON 欄位名稱，讓回應中的欄位名稱與請求一致
+	if engine, isValid := binding.Validator.Engine().(*validator.Validate); isValid {
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// validationErrorMessage 將 binding 驗證錯誤轉成指出欄位的訊息，其他錯誤則回傳通用訊息
+func validationErrorMessage(err error) string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) || len(validationErrors) == 0 {
+		return "Invalid input"
+	}
+
+	fieldError := validationErrors[0]
+	field := fieldError.Field()
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters", field, fieldError.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters", field, fieldError.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, fieldError.Param())
+	}
+	return fmt.Sprintf("%s is invalid", field)
+}
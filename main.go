@@ -10,9 +10,15 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,12 +26,21 @@ import (
 
 	"github.com/Walter1412/micro-backend/config"
 	"github.com/Walter1412/micro-backend/docs"
+	"github.com/Walter1412/micro-backend/migrations"
+	"github.com/Walter1412/micro-backend/models"
 	"github.com/Walter1412/micro-backend/routes"
+	"github.com/Walter1412/micro-backend/services"
 )
 
+// shutdownTimeout 為收到停止訊號後等待進行中請求完成的時間
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	// 載入配置
 	configuration := config.LoadConfig()
+	if configuration.Server.JWTSecret == "" {
+		log.Fatal("❌ JWT_SECRET is not set")
+	}
 	
 	// 設定 Gin 模式（生產環境使用 release 模式）
 	if configuration.Server.Port == "8080" { // 假設生產環境用 8080
@@ -35,6 +50,7 @@ func main() {
 	// 設定 Swagger 變數
 	docs.SwaggerInfo.Host = configuration.Swagger.Host
 	docs.SwaggerInfo.Schemes = []string{configuration.Swagger.Scheme}
+	docs.SwaggerInfo.BasePath = configuration.Server.APIBasePath
 
 	// 連接資料庫
 	database, err := sql.Open("mysql", configuration.GetDSN())
@@ -43,30 +59,104 @@ func main() {
 	}
 	defer database.Close()
 
-	// 自動重試 DB 連線
-	maxRetries := 10
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if err := database.Ping(); err == nil {
-			fmt.Println("✅ Connected to DB!")
-			break
-		} else {
-			fmt.Printf("⏳ Waiting for DB... (attempt %d/%d)\n", attempt, maxRetries)
-			time.Sleep(2 * time.Second)
+	// 連線池設定，避免流量高峰時開太多 MySQL 連線（too many connections）
+	database.SetMaxOpenConns(configuration.DB.MaxOpenConns)
+	database.SetMaxIdleConns(configuration.DB.MaxIdleConns)
+	database.SetConnMaxLifetime(configuration.DB.ConnMaxLifetime)
+
+	// 自動重試 DB 連線（指數退避）
+	if err := waitForDatabase(database, configuration.DB); err != nil {
+		slog.Error("DB not reachable after retrying", "error", err)
+		os.Exit(1)
+	}
+
+	// 套用尚未執行的 migrations，確保路由註冊前資料表都已存在
+	if configuration.DB.AutoMigrate {
+		if err := migrations.Up(database, configuration.DB.Name); err != nil {
+			slog.Error("Failed to apply migrations", "error", err)
+			os.Exit(1)
 		}
-		if attempt == maxRetries {
-			log.Fatal("❌ DB not reachable after retrying.")
+	}
+
+	// 開啟標籤正規化時先合併既有的重複標籤，之後新增的標籤一律為小寫
+	if configuration.Server.NormalizeLabels {
+		merged, err := models.MergeDuplicateTaskLabelsContext(context.Background(), database)
+		if err != nil {
+			slog.Error("Failed to merge duplicate task labels", "error", err)
+			os.Exit(1)
 		}
+		slog.Info("Normalized task labels", "merged", merged)
 	}
 
 	// 初始化路由
-	router := gin.Default()
+	// 請求 log 與 panic recovery 由 RegisterRoutes 掛上的中介層負責，因此不使用 gin.Default
+	router := gin.New()
 	
-	// 設定信任的代理（安全配置）
-	router.SetTrustedProxies([]string{"127.0.0.1", "::1"}) // 只信任本地代理
+	// 設定信任的代理（安全配置），未設定時 ClientIP 一律使用直接連線的位址
+	if err := router.SetTrustedProxies(configuration.Server.TrustedProxies); err != nil {
+		log.Fatal("❌ Invalid TRUSTED_PROXIES:", err)
+	}
 	
 	routes.RegisterRoutes(router, database, configuration)
 
+	// 收到 SIGINT / SIGTERM 時停止背景工作並關閉 server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cleanupDone := make(chan struct{})
+	go func() {
+		defer close(cleanupDone)
+		services.RunCleanup(ctx, database, configuration.Server.CleanupInterval)
+	}()
+
+	server := &http.Server{
+		Addr:    ":" + configuration.Server.Port,
+		Handler: router,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Server shutdown failed", "error", err)
+		}
+	}()
+
 	fmt.Println("🚀 Server running at http://localhost:" + configuration.Server.Port)
 	fmt.Println("🌐 Swagger UI available at http://localhost:" + configuration.Server.Port + "/swagger/index.html")
-	router.Run(":" + configuration.Server.Port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Error("Server stopped", "error", err)
+		stop()
+	}
+	<-cleanupDone
+}
+
+// waitForDatabase 以指數退避重試 Ping，直到成功、超過次數上限或超過總等待時間
+func waitForDatabase(database *sql.DB, dbConfig config.DBConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbConfig.ConnectTimeout)
+	defer cancel()
+
+	delay := dbConfig.ConnectRetryBase
+	for attempt := 1; ; attempt++ {
+		err := database.PingContext(ctx)
+		if err == nil {
+			slog.Info("Connected to DB", "attempt", attempt)
+			return nil
+		}
+		if dbConfig.ConnectMaxAttempts > 0 && attempt >= dbConfig.ConnectMaxAttempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		slog.Warn("Waiting for DB", "attempt", attempt, "retry_in", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s (%d attempts): %w", dbConfig.ConnectTimeout, attempt, err)
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > dbConfig.ConnectRetryMax {
+			delay = dbConfig.ConnectRetryMax
+		}
+	}
 }
@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bodyLimitKey 存放此路由的內容上限，讓 DecompressMiddleware 以同一個上限限制解壓後的大小
+const bodyLimitKey = "body_limit"
+
+// BodyLimitMiddleware 以 http.MaxBytesReader 限制請求內容大小，超過時在 handler 綁定之前回傳 413。
+// overrides 以路由樣式（c.FullPath()，例如 /api/v1/plans/import）指定個別路由的上限，
+// 全域中介層在路由比對後才執行，因此可以放寬批次、匯入等端點而不影響其他路由；上限 <= 0 代表不限制
+func BodyLimitMiddleware(maxBytes int64, overrides map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxBytes
+		if override, found := overrides[c.FullPath()]; found {
+			limit = override
+		}
+		c.Set(bodyLimitKey, limit)
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			abortBodyTooLarge(c)
+			return
+		}
+
+		// 沒有 Content-Length（chunked）時也必須在綁定前讀完，才能以 413 回應
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			abortBodyTooLarge(c)
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"code": "INVALID_INPUT", "error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		c.Next()
+	}
+}
+
+func abortBodyTooLarge(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"code": "PAYLOAD_TOO_LARGE", "error": "request body too large"})
+}
@@ -0,0 +1,132 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newBodyLimitRouter(maxBytes int64, overrides map[string]int64, handlerCalled *bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimitMiddleware(maxBytes, overrides))
+	router.Use(DecompressMiddleware(1 << 20))
+	handler := func(c *gin.Context) {
+		*handlerCalled = true
+		var payload map[string]interface{}
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, payload)
+	}
+	router.POST("/small", handler)
+	router.POST("/bulk", handler)
+	return router
+}
+
+func jsonBody(size int) string {
+	return `{"data":"` + strings.Repeat("a", size) + `"}`
+}
+
+func gzipBody(t *testing.T, body string) *bytes.Buffer {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buffer
+}
+
+func TestBodyLimitRejectsOversizedBodyBeforeBinding(t *testing.T) {
+	handlerCalled := false
+	router := newBodyLimitRouter(64, nil, &handlerCalled)
+
+	request := httptest.NewRequest(http.MethodPost, "/small", strings.NewReader(jsonBody(100)))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", recorder.Code)
+	}
+	if handlerCalled {
+		t.Fatal("handler should not run when the body is too large")
+	}
+	if !strings.Contains(recorder.Body.String(), "PAYLOAD_TOO_LARGE") {
+		t.Fatalf("expected PAYLOAD_TOO_LARGE code, got %s", recorder.Body.String())
+	}
+}
+
+func TestBodyLimitRejectsChunkedOversizedBody(t *testing.T) {
+	handlerCalled := false
+	router := newBodyLimitRouter(64, nil, &handlerCalled)
+
+	request := httptest.NewRequest(http.MethodPost, "/small", strings.NewReader(jsonBody(100)))
+	request.Header.Set("Content-Type", "application/json")
+	request.ContentLength = -1
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusRequestEntityTooLarge || handlerCalled {
+		t.Fatalf("expected 413 without calling the handler, got %d (handler called: %v)", recorder.Code, handlerCalled)
+	}
+}
+
+func TestBodyLimitAllowsOverrideRoute(t *testing.T) {
+	handlerCalled := false
+	router := newBodyLimitRouter(64, map[string]int64{"/bulk": 1024}, &handlerCalled)
+
+	request := httptest.NewRequest(http.MethodPost, "/bulk", strings.NewReader(jsonBody(100)))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 on override route, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestBodyLimitCapsDecompressedSize(t *testing.T) {
+	handlerCalled := false
+	router := newBodyLimitRouter(256, nil, &handlerCalled)
+
+	// 高度重複的內容壓縮後遠小於上限，解壓後仍須受同一個上限限制
+	compressed := gzipBody(t, jsonBody(4096))
+	if compressed.Len() > 256 {
+		t.Fatalf("compressed body unexpectedly large: %d bytes", compressed.Len())
+	}
+	request := httptest.NewRequest(http.MethodPost, "/small", compressed)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Content-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusRequestEntityTooLarge || handlerCalled {
+		t.Fatalf("expected 413 without calling the handler, got %d (handler called: %v)", recorder.Code, handlerCalled)
+	}
+}
+
+func TestBodyLimitAllowsSmallCompressedBody(t *testing.T) {
+	handlerCalled := false
+	router := newBodyLimitRouter(256, nil, &handlerCalled)
+
+	request := httptest.NewRequest(http.MethodPost, "/small", gzipBody(t, jsonBody(100)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Content-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
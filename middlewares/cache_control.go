@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControlMiddleware 設定回應的 Cache-Control。
+// maxAge > 0 時 GET/HEAD 允許私有快取 max-age 秒（依 Authorization 區分），其餘情況一律 no-store。
+func CacheControlMiddleware(maxAge time.Duration) gin.HandlerFunc {
+	seconds := int(maxAge / time.Second)
+	return func(context *gin.Context) {
+		method := context.Request.Method
+		if seconds > 0 && (method == http.MethodGet || method == http.MethodHead) {
+			context.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", seconds))
+			context.Writer.Header().Add("Vary", "Authorization")
+		} else {
+			context.Header("Cache-Control", "no-store")
+		}
+		context.Next()
+	}
+}
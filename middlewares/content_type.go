@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireJSONMiddleware 要求 POST / PUT / PATCH 請求的 Content-Type 為 application/json，否則回傳 415。
+// 沒有內容的請求（例如 PATCH /complete、POST /restore）不需要 Content-Type
+func RequireJSONMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength == 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"code":  "UNSUPPORTED_MEDIA_TYPE",
+				"error": "Content-Type must be application/json",
+			})
+			return
+		}
+		c.Next()
+	}
+}
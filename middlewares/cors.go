@@ -1,24 +1,33 @@
 package middlewares
 
 import (
-	"os"
+	"strconv"
+	"strings"
 
+	"github.com/Walter1412/micro-backend/config"
 	"github.com/gin-gonic/gin"
 )
 
-func CORSMiddleware() gin.HandlerFunc {
-	return func(context *gin.Context) {
-		origin := os.Getenv("FRONTEND_ORIGIN")
-		if origin == "" {
-			origin = "*" // fallback
-		}
+func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
+	origin := cfg.Server.FrontendOrigin
+	if origin == "" {
+		origin = "*" // fallback
+	}
+	allowedHeaders := strings.Join(cfg.CORS.AllowedHeaders, ", ")
+	allowedMethods := strings.Join(cfg.CORS.AllowedMethods, ", ")
+	maxAge := strconv.Itoa(int(cfg.CORS.MaxAge.Seconds()))
 
+	return func(context *gin.Context) {
 		context.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 		context.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		context.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		context.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		context.Writer.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+		context.Writer.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+		// 讓前端可以讀到 request ID，回報問題時附上
+		context.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if context.Request.Method == "OPTIONS" {
+			// 讓瀏覽器快取 preflight 結果，減少 OPTIONS 請求
+			context.Writer.Header().Set("Access-Control-Max-Age", maxAge)
 			context.AbortWithStatus(204)
 			return
 		}
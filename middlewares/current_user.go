@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+const currentUserKey = "current_user"
+
+type cachedUser struct {
+	user      *models.User
+	expiresAt time.Time
+}
+
+// UserCache 為 LoadUserMiddleware 使用的短暫快取。
+// 修改使用者資料（更新個人資訊、重設密碼、刪除帳號）的 handler 必須呼叫 Evict，避免在 ttl 內讀到舊資料
+type UserCache struct {
+	database *sql.DB
+	ttl      time.Duration
+	mutex    sync.Mutex
+	entries  map[int64]cachedUser
+}
+
+// NewUserCache 建立使用者快取，ttl 為每筆資料保留的時間
+func NewUserCache(database *sql.DB, ttl time.Duration) *UserCache {
+	return &UserCache{
+		database: database,
+		ttl:      ttl,
+		entries:  make(map[int64]cachedUser),
+	}
+}
+
+// Evict 移除使用者的快取，下一個請求會重新從 DB 載入
+func (cache *UserCache) Evict(userIdentifier int64) {
+	if cache == nil {
+		return
+	}
+	cache.mutex.Lock()
+	delete(cache.entries, userIdentifier)
+	cache.mutex.Unlock()
+}
+
+// LoadUserMiddleware 在 JWTAuthMiddleware 之後載入目前使用者並放進 context，
+// 讓 handler 透過 CurrentUser 重複使用，不必各自查詢。結果會短暫快取在 cache 中。
+// 僅掛在需要完整使用者資料的路由上，避免不必要的 DB 查詢。
+func LoadUserMiddleware(cache *UserCache) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		userIdentifier := context.GetInt64("user_id")
+		now := time.Now()
+
+		cache.mutex.Lock()
+		entry, exists := cache.entries[userIdentifier]
+		cache.mutex.Unlock()
+
+		if !exists || now.After(entry.expiresAt) {
+			user, error := models.GetUserByID(cache.database, int(userIdentifier))
+			if errors.Is(error, sql.ErrNoRows) {
+				// token 仍有效但帳號已在簽發後刪除
+				context.AbortWithStatusJSON(http.StatusNotFound, models.APIError{
+					Code:      "USER_NOT_FOUND",
+					Message:   "User not found",
+					RequestID: RequestIDFromContext(context),
+				})
+				return
+			}
+			if error != nil {
+				log.Printf("❌ Failed to load user %d: %v", userIdentifier, error)
+				context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+				return
+			}
+
+			entry = cachedUser{user: user, expiresAt: now.Add(cache.ttl)}
+			cache.mutex.Lock()
+			// 順便清掉過期的項目，避免快取無限成長
+			for identifier, cached := range cache.entries {
+				if now.After(cached.expiresAt) {
+					delete(cache.entries, identifier)
+				}
+			}
+			cache.entries[userIdentifier] = entry
+			cache.mutex.Unlock()
+		}
+
+		context.Set(currentUserKey, entry.user)
+		context.Next()
+	}
+}
+
+// CurrentUser 取得 LoadUserMiddleware 載入的使用者；路由沒有掛該中介層時回傳 false。
+// 回傳的資料與快取共用，需要修改時請先複製一份
+func CurrentUser(context *gin.Context) (*models.User, bool) {
+	value, exists := context.Get(currentUserKey)
+	if !exists {
+		return nil, false
+	}
+	user, isValid := value.(*models.User)
+	return user, isValid
+}
@@ -0,0 +1,97 @@
+package middlewares
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Walter1412/micro-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func expectUserQuery(mock sqlmock.Sqlmock, username string) {
+	mock.ExpectQuery("SELECT id, username, email, password_hash, is_verified, last_login_at, created_at FROM users WHERE id = ?").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "password_hash", "is_verified", "last_login_at", "created_at"}).
+			AddRow(7, username, "w@w.com", "hash", true, nil, time.Now()))
+}
+
+func TestLoadUserMiddlewareCachesUntilEvicted(t *testing.T) {
+	database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer database.Close()
+
+	cache := NewUserCache(database, time.Minute)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/profile", func(c *gin.Context) { c.Set("user_id", int64(7)) }, LoadUserMiddleware(cache), func(c *gin.Context) {
+		user, isLoaded := CurrentUser(c)
+		if !isLoaded {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, user.Username)
+	})
+	get := func() string {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/profile", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", recorder.Code)
+		}
+		return recorder.Body.String()
+	}
+
+	expectUserQuery(mock, "walter")
+	if name := get(); name != "walter" {
+		t.Fatalf("expected walter, got %q", name)
+	}
+	// 第二次請求使用快取，不應再查詢
+	if name := get(); name != "walter" {
+		t.Fatalf("expected cached walter, got %q", name)
+	}
+
+	cache.Evict(7)
+	expectUserQuery(mock, "renamed")
+	if name := get(); name != "renamed" {
+		t.Fatalf("expected reloaded user after Evict, got %q", name)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadUserMiddlewareReturnsNotFoundForDeletedUser(t *testing.T) {
+	database, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer database.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/profile", func(c *gin.Context) { c.Set("user_id", int64(7)) }, LoadUserMiddleware(NewUserCache(database, time.Minute)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// token 簽發後帳號已被刪除
+	mock.ExpectQuery("FROM users WHERE id = ?").WithArgs(7).WillReturnError(sql.ErrNoRows)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/profile", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var body models.APIError
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Code != "USER_NOT_FOUND" {
+		t.Fatalf("expected USER_NOT_FOUND, got %q", body.Code)
+	}
+}
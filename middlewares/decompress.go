@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DecompressMiddleware 在 ShouldBindJSON 之前解壓縮 Content-Encoding 為 gzip 或 deflate 的請求內容。
+// 解壓後超過 maxBytes 或 BodyLimitMiddleware 給此路由的上限（取較小者）回傳 413（避免 zip bomb），壓縮格式錯誤回傳 400。
+func DecompressMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(context.GetHeader("Content-Encoding")))
+		if encoding == "" || encoding == "identity" || context.Request.Body == nil {
+			context.Next()
+			return
+		}
+
+		var reader io.ReadCloser
+		var error error
+		switch encoding {
+		case "gzip", "x-gzip":
+			reader, error = gzip.NewReader(context.Request.Body)
+		case "deflate":
+			reader, error = zlib.NewReader(context.Request.Body)
+		default:
+			context.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported Content-Encoding"})
+			return
+		}
+		if error != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Malformed compressed body"})
+			return
+		}
+		defer reader.Close()
+
+		limit := maxBytes
+		if routeLimit := context.GetInt64(bodyLimitKey); routeLimit > 0 && routeLimit < limit {
+			limit = routeLimit
+		}
+
+		// 多讀 1 byte 以判斷是否超過上限
+		decompressed, error := io.ReadAll(io.LimitReader(reader, limit+1))
+		if error != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Malformed compressed body"})
+			return
+		}
+		if int64(len(decompressed)) > limit {
+			context.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"code": "PAYLOAD_TOO_LARGE", "error": "Decompressed body too large"})
+			return
+		}
+
+		context.Request.Body = io.NopCloser(bytes.NewReader(decompressed))
+		context.Request.ContentLength = int64(len(decompressed))
+		context.Request.Header.Del("Content-Encoding")
+		context.Request.Header.Set("Content-Length", strconv.Itoa(len(decompressed)))
+
+		context.Next()
+	}
+}
@@ -3,14 +3,18 @@ package middlewares
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
+	"github.com/Walter1412/micro-backend/config"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func JWTAuthMiddleware() gin.HandlerFunc {
+func JWTAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	secret := cfg.Server.JWTSecret
+	options := parserOptions(cfg.Server.JWTStrict, cfg.Server.JWTLeeway)
+
 	return func(context *gin.Context) {
 		authHeader := context.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
@@ -19,18 +23,13 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "JWT secret not configured"})
-			return
-		}
 
 		token, error := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			if _, isValid := token.Method.(*jwt.SigningMethodHMAC); !isValid {
 				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 			}
 			return []byte(secret), nil
-		})
+		}, options...)
 
 		if error != nil || !token.Valid {
 			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -51,3 +50,17 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		}
 	}
 }
+
+// parserOptions 嚴格模式下拒絕沒有 exp 的 token（這種 token 永遠不會過期），
+// iat / nbf 有帶時也會驗證。JWT_STRICT=false 可關閉（僅供相容舊 token）。
+// leeway 讓時鐘稍有誤差的客戶端不會在 token 邊界收到 401。
+func parserOptions(strict bool, leeway time.Duration) []jwt.ParserOption {
+	options := []jwt.ParserOption{jwt.WithLeeway(leeway)}
+	if !strict {
+		return options
+	}
+	return append(options,
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+}
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Walter1412/micro-backend/config"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testJWTSecret = "test-secret"
+
+func newJWTRouter(strict bool, leeway time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Server: config.ServerConfig{JWTSecret: testJWTSecret, JWTStrict: strict, JWTLeeway: leeway}}
+	router := gin.New()
+	router.GET("/protected", JWTAuthMiddleware(cfg), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt64("user_id")})
+	})
+	return router
+}
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func requestWithToken(router *gin.Engine, token string) int {
+	request := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestJWTStrictModeRejectsTokenWithoutExp(t *testing.T) {
+	token := signTestToken(t, jwt.MapClaims{"user_id": 1, "iat": time.Now().Unix()})
+
+	if code := requestWithToken(newJWTRouter(true, 0), token); code != http.StatusUnauthorized {
+		t.Fatalf("JWT_STRICT=true: expected 401 for a token without exp, got %d", code)
+	}
+}
+
+func TestJWTNonStrictModeAcceptsTokenWithoutExp(t *testing.T) {
+	token := signTestToken(t, jwt.MapClaims{"user_id": 1, "iat": time.Now().Unix()})
+
+	if code := requestWithToken(newJWTRouter(false, 0), token); code != http.StatusOK {
+		t.Fatalf("JWT_STRICT=false: expected 200 for a token without exp, got %d", code)
+	}
+}
+
+func TestJWTLeewayAcceptsTokenJustPastExpiry(t *testing.T) {
+	now := time.Now()
+	token := signTestToken(t, jwt.MapClaims{"user_id": 1, "iat": now.Add(-time.Hour).Unix(), "exp": now.Add(-10 * time.Second).Unix()})
+
+	if code := requestWithToken(newJWTRouter(true, 30*time.Second), token); code != http.StatusOK {
+		t.Fatalf("expected 200 for a token expired 10s ago with 30s leeway, got %d", code)
+	}
+}
+
+func TestJWTLeewayRejectsTokenBeyondLeeway(t *testing.T) {
+	now := time.Now()
+	token := signTestToken(t, jwt.MapClaims{"user_id": 1, "iat": now.Add(-time.Hour).Unix(), "exp": now.Add(-time.Minute).Unix()})
+
+	if code := requestWithToken(newJWTRouter(true, 30*time.Second), token); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a token expired 60s ago with 30s leeway, got %d", code)
+	}
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLoggingMiddleware 每個請求輸出一行結構化 log（method、path、status、latency、client IP、
+// request ID，已登入時附上 user_id）。format 為 "json" 時輸出 JSON，其餘輸出方便本機閱讀的 key=value 格式。
+func RequestLoggingMiddleware(format string) gin.HandlerFunc {
+	var handler slog.Handler
+	if format == "json" {
+		handler = slog.NewJSONHandler(os.Stdout, nil)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, nil)
+	}
+	logger := slog.New(handler)
+
+	return func(context *gin.Context) {
+		start := time.Now()
+		context.Next()
+
+		attributes := []any{
+			"method", context.Request.Method,
+			"path", context.Request.URL.Path,
+			"status", context.Writer.Status(),
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", context.ClientIP(),
+			"request_id", RequestIDFromContext(context),
+		}
+		if userIdentifier, exists := context.Get("user_id"); exists {
+			attributes = append(attributes, "user_id", userIdentifier)
+		}
+
+		level := slog.LevelInfo
+		if context.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		logger.Log(context.Request.Context(), level, "request", attributes...)
+	}
+}
@@ -2,36 +2,127 @@ package middlewares
 
 import (
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
 
-var (
-	// 全域限制器：每秒100個請求，突發200個（適合小型網站100-500用戶）
-	globalLimiter = rate.NewLimiter(rate.Limit(100), 200)
-)
+// RateLimitMiddleware 全域請求頻率限制中間件，所有請求共用同一個 limiter；allowlist 內的 IP 不受限制
+func RateLimitMiddleware(limiter *rate.Limiter, allowlist []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isAllowlisted(c, allowlist) {
+			c.Next()
+			return
+		}
+		if !limiter.Allow() {
+			abortRateLimited(c, limiter)
+			return
+		}
+		c.Next()
+	}
+}
 
-// RateLimitMiddleware 全域請求頻率限制中間件
-func RateLimitMiddleware() gin.HandlerFunc {
+// IPRateLimitMiddleware 依用戶端 IP 限制請求頻率，掛在全域限制之後，避免單一用戶端用光所有額度；allowlist 內的 IP 不受限制
+func IPRateLimitMiddleware(limiters *IPRateLimiter, allowlist []*net.IPNet) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !globalLimiter.Allow() {
-			// 計算下次允許請求的等待時間
-			reservation := globalLimiter.Reserve()
-			delay := reservation.Delay()
-			reservation.Cancel() // 取消預約，不實際等待
-			
-			retryAfterSeconds := int(delay.Seconds()) + 1 // 向上取整並加1秒緩衝
-			
-			c.Header("Retry-After", fmt.Sprintf("%d", retryAfterSeconds))
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
-				"retry_after": fmt.Sprintf("%ds", retryAfterSeconds),
-				"message":     "Too many requests, please try again later",
-			})
+		if isAllowlisted(c, allowlist) {
+			c.Next()
+			return
+		}
+		limiter := limiters.Limiter(c.ClientIP())
+		if !limiter.Allow() {
+			abortRateLimited(c, limiter)
 			return
 		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// isAllowlisted 判斷用戶端 IP 是否在 allowlist 中。
+// c.ClientIP() 只在直接連線的來源是信任的 proxy 時才採用 X-Forwarded-For，因此無法以偽造的標頭繞過限制
+func isAllowlisted(c *gin.Context, allowlist []*net.IPNet) bool {
+	if len(allowlist) == 0 {
+		return false
+	}
+	ip := net.ParseIP(c.ClientIP())
+	if ip == nil {
+		return false
+	}
+	for _, network := range allowlist {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// abortRateLimited 回傳 429 與 Retry-After
+func abortRateLimited(c *gin.Context, limiter *rate.Limiter) {
+	// 計算下次允許請求的等待時間
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	reservation.Cancel() // 取消預約，不實際等待
+
+	retryAfterSeconds := int(delay.Seconds()) + 1 // 向上取整並加1秒緩衝
+
+	c.Header("Retry-After", fmt.Sprintf("%d", retryAfterSeconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Rate limit exceeded",
+		"retry_after": fmt.Sprintf("%ds", retryAfterSeconds),
+		"message":     "Too many requests, please try again later",
+	})
+}
+
+type ipLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// IPRateLimiter 為每個 IP 維護各自的 rate.Limiter，閒置超過 idleTTL 的項目會被清除以限制記憶體用量
+type IPRateLimiter struct {
+	mutex       sync.Mutex
+	limiters    map[string]*ipLimiterEntry
+	limit       rate.Limit
+	burst       int
+	idleTTL     time.Duration
+	lastCleanup time.Time
+}
+
+func NewIPRateLimiter(rps float64, burst int, idleTTL time.Duration) *IPRateLimiter {
+	return &IPRateLimiter{
+		limiters:    make(map[string]*ipLimiterEntry),
+		limit:       rate.Limit(rps),
+		burst:       burst,
+		idleTTL:     idleTTL,
+		lastCleanup: time.Now(),
+	}
+}
+
+// Limiter 取得（必要時建立）該 IP 的限制器
+func (l *IPRateLimiter) Limiter(ip string) *rate.Limiter {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	// 最多每 idleTTL 掃描一次，清掉閒置的 IP
+	if now.Sub(l.lastCleanup) >= l.idleTTL {
+		for key, entry := range l.limiters {
+			if now.Sub(entry.lastSeen) >= l.idleTTL {
+				delete(l.limiters, key)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	entry, exists := l.limiters[ip]
+	if !exists {
+		entry = &ipLimiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[ip] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter
+}
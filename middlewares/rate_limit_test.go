@@ -0,0 +1,117 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+func newRateLimitedRouter(middlewares ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares...)
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func requestFrom(router *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	request.RemoteAddr = remoteAddr
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRateLimitBeyondBurstReturns429(t *testing.T) {
+	// 每秒補充極少，突發上限 2：前兩個請求通過，第三個被擋下
+	router := newRateLimitedRouter(RateLimitMiddleware(rate.NewLimiter(rate.Limit(0.001), 2), nil))
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if code := requestFrom(router, "192.0.2.1:1234").Code; code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", attempt, code)
+		}
+	}
+	recorder := requestFrom(router, "192.0.2.1:1234")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 beyond the burst, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Fatal("expected a Retry-After header")
+	}
+}
+
+func TestIPRateLimitBeyondBurstReturns429PerIP(t *testing.T) {
+	router := newRateLimitedRouter(IPRateLimitMiddleware(NewIPRateLimiter(0.001, 1, time.Minute), nil))
+
+	if code := requestFrom(router, "192.0.2.1:1234").Code; code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := requestFrom(router, "192.0.2.1:1234").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 beyond the burst, got %d", code)
+	}
+	// 其他 IP 有自己的額度
+	if code := requestFrom(router, "192.0.2.2:1234").Code; code != http.StatusOK {
+		t.Fatalf("expected 200 for another IP, got %d", code)
+	}
+}
+
+func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
+
+func TestRateLimitAllowlistedIPBypassesLimit(t *testing.T) {
+	allowlist := []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+	limiter := rate.NewLimiter(rate.Limit(0.001), 1)
+	router := newRateLimitedRouter(
+		RateLimitMiddleware(limiter, allowlist),
+		IPRateLimitMiddleware(NewIPRateLimiter(0.001, 1, time.Minute), allowlist),
+	)
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		if code := requestFrom(router, "10.1.2.3:1234").Code; code != http.StatusOK {
+			t.Fatalf("request %d from an allowlisted IP: expected 200, got %d", attempt, code)
+		}
+	}
+}
+
+func TestRateLimitNonAllowlistedIPIsLimited(t *testing.T) {
+	allowlist := []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+	router := newRateLimitedRouter(IPRateLimitMiddleware(NewIPRateLimiter(0.001, 1, time.Minute), allowlist))
+
+	if code := requestFrom(router, "192.0.2.1:1234").Code; code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := requestFrom(router, "192.0.2.1:1234").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for an IP outside the allowlist, got %d", code)
+	}
+}
+
+func TestRateLimitAllowlistIgnoresSpoofedForwardedFor(t *testing.T) {
+	allowlist := []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+	router := newRateLimitedRouter(IPRateLimitMiddleware(NewIPRateLimiter(0.001, 1, time.Minute), allowlist))
+	// 沒有設定信任的 proxy，X-Forwarded-For 不會被採用
+	if err := router.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		request.Header.Set("X-Forwarded-For", "10.1.2.3")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if attempt == 2 && recorder.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected a spoofed X-Forwarded-For not to bypass the limit, got %d", recorder.Code)
+		}
+	}
+}
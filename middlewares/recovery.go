@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryMiddleware 攔截 handler 的 panic，記錄 stack 與 request ID，並回傳 JSON 格式的 500，
+// 取代 gin 預設 recovery 的非 JSON 回應。
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				// 讓 net/http 照常中斷連線
+				panic(recovered)
+			}
+
+			log.Printf("❌ Panic recovered: request_id=%s method=%s path=%s: %v\n%s",
+				RequestIDFromContext(context), context.Request.Method, context.Request.URL.Path, recovered, debug.Stack())
+
+			if context.Writer.Written() {
+				// 回應已經開始送出，無法再改寫狀態碼
+				context.Abort()
+				return
+			}
+			context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}()
+		context.Next()
+	}
+}
@@ -0,0 +1,100 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+	// maxRequestIDLength 限制用戶端傳入的 ID 長度，避免塞入過長的內容到 log
+	maxRequestIDLength = 128
+)
+
+// RequestIDMiddleware 沿用用戶端帶入的 X-Request-ID（格式不合法時忽略），否則產生 UUID，
+// 存進 context 並設定在回應標頭上；JSON 錯誤回應（status >= 400）會自動加上 request_id 欄位，
+// 讓使用者回報問題時可以附上。
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		requestIdentifier := context.GetHeader(requestIDHeader)
+		if !isValidRequestID(requestIdentifier) {
+			requestIdentifier = newRequestID()
+		}
+
+		context.Set(requestIDKey, requestIdentifier)
+		context.Header(requestIDHeader, requestIdentifier)
+		context.Writer = &requestIDWriter{ResponseWriter: context.Writer, requestID: requestIdentifier}
+		context.Next()
+	}
+}
+
+// RequestIDFromContext 取得 RequestIDMiddleware 設定的 request ID，沒有時回傳空字串
+func RequestIDFromContext(context *gin.Context) string {
+	return context.GetString(requestIDKey)
+}
+
+func isValidRequestID(value string) bool {
+	if value == "" || len(value) > maxRequestIDLength {
+		return false
+	}
+	for _, character := range value {
+		if character < '!' || character > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 產生隨機的 UUID v4
+func newRequestID() string {
+	var buffer [16]byte
+	if _, error := rand.Read(buffer[:]); error != nil {
+		// crypto/rand 幾乎不會失敗，失敗時仍回傳可辨識的值而不是中斷請求
+		return "unknown"
+	}
+	buffer[6] = (buffer[6] & 0x0f) | 0x40
+	buffer[8] = (buffer[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buffer[0:4], buffer[4:6], buffer[6:8], buffer[8:10], buffer[10:16])
+}
+
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+}
+
+func (writer *requestIDWriter) Write(data []byte) (int, error) {
+	if writer.Status() >= 400 && strings.HasPrefix(writer.Header().Get("Content-Type"), "application/json") {
+		if _, error := writer.ResponseWriter.Write(withRequestID(data, writer.requestID)); error != nil {
+			return 0, error
+		}
+		return len(data), nil
+	}
+	return writer.ResponseWriter.Write(data)
+}
+
+// withRequestID 在 JSON 物件中加入 request_id；不是 JSON 物件或已經有該欄位時原樣回傳
+func withRequestID(data []byte, requestIdentifier string) []byte {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return data
+	}
+	var body map[string]json.RawMessage
+	if error := json.Unmarshal(data, &body); error != nil {
+		return data
+	}
+	if _, exists := body[requestIDKey]; exists {
+		return data
+	}
+	encoded, _ := json.Marshal(requestIdentifier)
+	body[requestIDKey] = encoded
+	result, error := json.Marshal(body)
+	if error != nil {
+		return data
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware 為每個請求的 context 設定期限，handler 以 c.Request.Context() 呼叫 ...Context 版本的 DB 方法時，
+// 超過期限的查詢會被取消。handler 沒有寫出回應就結束時，由這裡回傳 503；timeout <= 0 代表不限制
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if RequestTimedOut(c) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"code": "REQUEST_TIMEOUT", "error": "request timeout"})
+		}
+	}
+}
+
+// RequestTimedOut 回傳請求是否已超過 TimeoutMiddleware 設定的期限
+func RequestTimedOut(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,2 @@
+DROP INDEX idx_tasks_user_completed_at ON tasks;
+ALTER TABLE tasks DROP COLUMN completed_at;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMP NULL DEFAULT NULL AFTER is_completed;
+
+-- 既有已完成的任務以最後更新時間作為完成時間
+UPDATE tasks SET completed_at = updated_at WHERE is_completed = TRUE;
+
+CREATE INDEX idx_tasks_user_completed_at ON tasks (user_id, completed_at);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE sections DROP COLUMN is_closed;
\ No newline at end of file
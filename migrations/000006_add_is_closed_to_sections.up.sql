@@ -0,0 +1 @@
+ALTER TABLE sections ADD COLUMN is_closed BOOLEAN NOT NULL DEFAULT FALSE AFTER sort_order;
\ No newline at end of file
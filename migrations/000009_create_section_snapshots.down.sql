@@ -0,0 +1 @@
+DROP TABLE IF EXISTS section_snapshots;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE section_snapshots (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  section_id BIGINT NOT NULL,
+  user_id INT NOT NULL,
+  tasks JSON NOT NULL,
+  task_count INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (section_id) REFERENCES sections(id) ON DELETE CASCADE,
+  INDEX idx_section_created_at (section_id, created_at)
+);
\ No newline at end of file
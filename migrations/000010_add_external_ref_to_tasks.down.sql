@@ -0,0 +1,2 @@
+DROP INDEX idx_tasks_user_external_ref ON tasks;
+ALTER TABLE tasks DROP COLUMN external_ref;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN external_ref VARCHAR(255) NULL DEFAULT NULL AFTER title;
+
+-- 每個使用者的 external_ref 不可重複（NULL 不受限制）
+CREATE UNIQUE INDEX idx_tasks_user_external_ref ON tasks (user_id, external_ref);
\ No newline at end of file
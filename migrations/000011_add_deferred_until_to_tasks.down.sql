@@ -0,0 +1,2 @@
+DROP INDEX idx_tasks_section_deferred_until ON tasks;
+ALTER TABLE tasks DROP COLUMN deferred_until;
\ No newline at end of file
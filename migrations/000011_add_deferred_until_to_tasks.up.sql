@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN deferred_until TIMESTAMP NULL DEFAULT NULL AFTER completed_at;
+
+CREATE INDEX idx_tasks_section_deferred_until ON tasks (section_id, deferred_until);
\ No newline at end of file
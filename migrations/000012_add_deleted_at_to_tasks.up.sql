@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL AFTER deferred_until;
+
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX idx_tasks_user_due_date ON tasks;
+ALTER TABLE tasks DROP COLUMN due_date;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN due_date TIMESTAMP NULL DEFAULT NULL AFTER completed_at;
+
+CREATE INDEX idx_tasks_user_due_date ON tasks (user_id, due_date);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN priority ENUM('low', 'medium', 'high') NOT NULL DEFAULT 'medium' AFTER is_completed;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS task_labels;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE task_labels (
+  task_id BIGINT NOT NULL,
+  label VARCHAR(50) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, label),
+  FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+  INDEX idx_label (label)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE sections DROP COLUMN archived;
\ No newline at end of file
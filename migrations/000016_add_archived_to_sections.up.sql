@@ -0,0 +1 @@
+ALTER TABLE sections ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE AFTER is_closed;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE sections ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER archived;
+ALTER TABLE tasks ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER sort_order;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN last_login_at TIMESTAMP NULL DEFAULT NULL AFTER is_verified;
\ No newline at end of file
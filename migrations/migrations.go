@@ -0,0 +1,209 @@
+// Package migrations 內嵌本目錄的 *.up.sql，並在 app 啟動時套用尚未執行的版本。
+//
+// 版本紀錄沿用 golang-migrate 的 schema_migrations 格式（單一列的 version 與 dirty），
+// 並使用相同的 advisory lock，因此可以和 docker compose 中的 migrate container 並存，
+// 也可以繼續用 migrate CLI 執行 down 或 force。
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.up.sql
+var files embed.FS
+
+const (
+	migrationsTable = "schema_migrations"
+	// advisoryLockSalt 與 golang-migrate 產生 advisory lock ID 的 salt 相同
+	advisoryLockSalt uint32 = 1486364155
+	lockTimeout             = 10 * time.Second
+)
+
+type migration struct {
+	Version uint64
+	Name    string
+}
+
+// Up 依版本順序套用所有尚未執行的 migration；已是最新版本時不做任何事。
+// 上一次 migration 執行失敗（dirty）時直接回傳錯誤，需要手動修復後再用 migrate force 標記版本。
+func Up(database *sql.DB, databaseName string) error {
+	pending, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	// GET_LOCK 綁定在連線上，整個流程必須使用同一條連線
+	connection, err := database.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	lockIdentifier := advisoryLockID(databaseName + ":" + migrationsTable)
+	var acquired sql.NullInt64
+	if err := connection.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockIdentifier, int(lockTimeout.Seconds())).Scan(&acquired); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return errors.New("acquire migration lock: timed out")
+	}
+	defer connection.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockIdentifier)
+
+	if _, err := connection.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+migrationsTable+" (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"); err != nil {
+		return fmt.Errorf("create %s: %w", migrationsTable, err)
+	}
+
+	current, dirty, err := currentVersion(ctx, connection)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d, fix it manually and run migrate force", current)
+	}
+
+	applied := 0
+	for _, item := range pending {
+		if item.Version <= current {
+			continue
+		}
+		if err := apply(ctx, connection, item); err != nil {
+			return err
+		}
+		applied++
+	}
+
+	if applied > 0 {
+		log.Printf("✅ Applied %d migration(s)", applied)
+	}
+	return nil
+}
+
+// apply 先把版本標記為 dirty 再執行 SQL，成功後才清除 dirty。
+// MySQL 的 DDL 會隱含 commit，transaction 只能保護 DML，因此以 dirty 標記中途失敗的版本。
+func apply(ctx context.Context, connection *sql.Conn, item migration) error {
+	content, err := files.ReadFile(item.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := setVersion(ctx, connection, item.Version, true); err != nil {
+		return err
+	}
+
+	transaction, err := connection.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, statement := range splitStatements(string(content)) {
+		if _, err := transaction.ExecContext(ctx, statement); err != nil {
+			transaction.Rollback()
+			return fmt.Errorf("migration %s: %w", item.Name, err)
+		}
+	}
+	if err := transaction.Commit(); err != nil {
+		return fmt.Errorf("migration %s: %w", item.Name, err)
+	}
+
+	if err := setVersion(ctx, connection, item.Version, false); err != nil {
+		return err
+	}
+	log.Printf("✅ Migration applied: %s", item.Name)
+	return nil
+}
+
+func currentVersion(ctx context.Context, connection *sql.Conn) (uint64, bool, error) {
+	var version uint64
+	var dirty bool
+	err := connection.QueryRowContext(ctx, "SELECT version, dirty FROM "+migrationsTable+" LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("read %s: %w", migrationsTable, err)
+	}
+	return version, dirty, nil
+}
+
+// setVersion 與 golang-migrate 相同，資料表中只保留一列目前的版本
+func setVersion(ctx context.Context, connection *sql.Conn, version uint64, dirty bool) error {
+	transaction, err := connection.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+
+	if _, err := transaction.ExecContext(ctx, "DELETE FROM "+migrationsTable); err != nil {
+		return fmt.Errorf("set version %d: %w", version, err)
+	}
+	if _, err := transaction.ExecContext(ctx, "INSERT INTO "+migrationsTable+" (version, dirty) VALUES (?, ?)", version, dirty); err != nil {
+		return fmt.Errorf("set version %d: %w", version, err)
+	}
+	return transaction.Commit()
+}
+
+// loadMigrations 讀取內嵌的 *.up.sql 並依檔名開頭的版本號排序
+func loadMigrations() ([]migration, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(names))
+	for _, name := range names {
+		prefix, _, found := strings.Cut(name, "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s: missing version prefix", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version prefix", name)
+		}
+		migrations = append(migrations, migration{Version: version, Name: name})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for index := 1; index < len(migrations); index++ {
+		if migrations[index].Version == migrations[index-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[index].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// splitStatements 去掉 -- 註解後以分號切開，DSN 沒有開啟 multiStatements，必須逐句執行
+func splitStatements(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var statements []string
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+// advisoryLockID 與 golang-migrate 的 GenerateAdvisoryLockId 相同，確保兩邊互斥
+func advisoryLockID(name string) string {
+	sum := crc32.ChecksumIEEE([]byte(name))
+	sum = sum * advisoryLockSalt
+	return strconv.FormatUint(uint64(sum), 10)
+}
@@ -0,0 +1,9 @@
+package models
+
+// APIError 為統一的錯誤回應格式，前端可依 code 判斷錯誤種類而不必比對訊息文字。
+// 訊息沿用既有回應的 "error" 欄位，舊版前端不需修改。
+type APIError struct {
+	Code      string `json:"code" example:"SECTION_NOT_FOUND"`
+	Message   string `json:"error" example:"Section not found"`
+	RequestID string `json:"request_id,omitempty" example:"3f1c2a9e-5b7d-4e2a-9c1f-0a8b6d4e2f10"`
+}
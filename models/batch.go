@@ -0,0 +1,40 @@
+package models
+
+// BatchOperation 為批次端點中的單一操作。
+// 既有資源以 id 指定；同一批次中先前建立的資源以 ref（對應其 temp_id）指定。
+type BatchOperation struct {
+	Op          string  `json:"op" binding:"required,oneof=create update delete move" example:"create"`
+	Type        string  `json:"type" binding:"required,oneof=section task" example:"task"`
+	TempID      string  `json:"temp_id,omitempty" example:"tmp-1"`
+	ID          *int64  `json:"id,omitempty"`
+	Ref         string  `json:"ref,omitempty"`
+	SectionID   *int64  `json:"section_id,omitempty"`
+	SectionRef  string  `json:"section_ref,omitempty"`
+	Title       *string `json:"title,omitempty"`
+	Content     *string `json:"content,omitempty"`
+	IsCompleted *bool   `json:"is_completed,omitempty"`
+	SortOrder   *int    `json:"sort_order,omitempty"`
+}
+
+type BatchInput struct {
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=200,dive"`
+}
+
+type BatchOperationResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Type   string `json:"type"`
+	TempID string `json:"temp_id,omitempty"`
+	ID     int64  `json:"id"`
+}
+
+// BatchError 為批次失敗時的錯誤回應，index 為失敗操作在 operations 中的位置（從 0 開始）
+type BatchError struct {
+	APIError
+	Index int `json:"index" example:"2"`
+}
+
+type BatchResult struct {
+	Results []BatchOperationResult `json:"results"`
+	IDs     map[string]int64       `json:"ids"`
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrVerificationTokenInvalid 表示驗證 token 不存在、已過期或已使用
+var ErrVerificationTokenInvalid = errors.New("verification token invalid or expired")
+
+type EmailVerification struct {
+	ID        int
+	UserID    int
+	Token     string
+	ExpiresAt time.Time
+	Used      bool
+	CreatedAt time.Time
+}
+
+// ErrEmailVerificationThrottled 表示該使用者在節流時間內已建立過驗證 token
+var ErrEmailVerificationThrottled = errors.New("email verification requested too recently")
+
+func CreateEmailVerification(database *sql.DB, userID int) (*EmailVerification, error) {
+	return CreateEmailVerificationContext(context.Background(), database, userID, 0)
+}
+
+// CreateEmailVerificationContext 建立新的驗證 token，並在同一個 transaction 中把該使用者先前未使用的 token 標記為已使用，
+// 確保只有最新寄出的連結有效。throttle 時間內已建立過 token 時回傳 ErrEmailVerificationThrottled（0 代表不節流），
+// ctx 取消或逾時時中止查詢
+func CreateEmailVerificationContext(ctx context.Context, database *sql.DB, userID int, throttle time.Duration) (*EmailVerification, error) {
+	token, err := generateResetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(time.Hour * 24) // 24 hour expiration
+
+	transaction, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer transaction.Rollback()
+
+	if throttle > 0 {
+		// 鎖定使用者資料列，避免同時送出的請求都通過節流檢查
+		var lockedID int
+		if err = transaction.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Scan(&lockedID); err != nil {
+			return nil, err
+		}
+
+		var recent bool
+		err = transaction.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM email_verifications WHERE user_id = ? AND created_at > NOW() - INTERVAL ? SECOND)",
+			userID, int64(throttle.Seconds()),
+		).Scan(&recent)
+		if err != nil {
+			return nil, err
+		}
+		if recent {
+			return nil, ErrEmailVerificationThrottled
+		}
+	}
+
+	// 先前寄出的連結一律失效，避免寄到舊 email 的連結驗證了尚未確認的新 email
+	_, err = transaction.ExecContext(ctx,
+		"UPDATE email_verifications SET used = TRUE WHERE user_id = ? AND used = FALSE",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = transaction.ExecContext(ctx,
+		"INSERT INTO email_verifications (user_id, token, expires_at) VALUES (?, ?, ?)",
+		userID, token, expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &EmailVerification{
+		UserID:    userID,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		Used:      false,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// VerifyEmail 使用驗證 token 並將對應使用者標記為已驗證，兩者在同一個 transaction 中完成
+func VerifyEmail(database *sql.DB, token string) (int, error) {
+	transaction, err := database.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer transaction.Rollback()
+
+	var userID int
+	err = transaction.QueryRow(
+		"SELECT user_id FROM email_verifications WHERE token = ? AND used = FALSE AND expires_at > NOW() FOR UPDATE",
+		token,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrVerificationTokenInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = transaction.Exec("UPDATE email_verifications SET used = TRUE WHERE token = ?", token); err != nil {
+		return 0, err
+	}
+	if _, err = transaction.Exec("UPDATE users SET is_verified = TRUE WHERE id = ?", userID); err != nil {
+		return 0, err
+	}
+
+	return userID, transaction.Commit()
+}
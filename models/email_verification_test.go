@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNewEmailVerificationInvalidatesPreviousLinks(t *testing.T) {
+	database, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer database.Close()
+
+	token := &capturedArg{}
+
+	// 更換 email 後建立新 token，寄到舊 email 的連結必須先失效
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE email_verifications SET used = TRUE WHERE user_id = \\? AND used = FALSE").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO email_verifications").WithArgs(7, token, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	verification, err := CreateEmailVerification(database, 7)
+	if err != nil {
+		t.Fatalf("CreateEmailVerification: %v", err)
+	}
+	if token.value != verification.Token {
+		t.Fatal("returned token does not match the inserted token")
+	}
+
+	// 舊 token 已被標記為 used，VerifyEmail 找不到它，也不會更新 users.is_verified
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id FROM email_verifications WHERE token = \\? AND used = FALSE AND expires_at > NOW\\(\\) FOR UPDATE").
+		WithArgs("old-token").WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	mock.ExpectRollback()
+
+	if _, err := VerifyEmail(database, "old-token"); !errors.Is(err, ErrVerificationTokenInvalid) {
+		t.Fatalf("expected ErrVerificationTokenInvalid for the old link, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+type ResourceFootprint struct {
+	Count       int   `json:"count"`
+	ApproxBytes int64 `json:"approx_bytes"`
+}
+
+type DataFootprint struct {
+	Sections ResourceFootprint `json:"sections"`
+	Tasks    ResourceFootprint `json:"tasks"`
+	// 區塊快照以 JSON 保存的任務內容
+	SectionSnapshots ResourceFootprint `json:"section_snapshots"`
+	TaskLabels       ResourceFootprint `json:"task_labels"`
+	TotalBytes       int64             `json:"total_bytes"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// OptionalTime 用於部分更新：區分「未提供」（Set 為 false）與「明確帶 null 以清除」（Set 為 true、Value 為 nil）
+type OptionalTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+func (optional *OptionalTime) UnmarshalJSON(data []byte) error {
+	optional.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		optional.Value = nil
+		return nil
+	}
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	optional.Value = &value
+	return nil
+}
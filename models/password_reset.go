@@ -1,12 +1,17 @@
 package models
 
 import (
+	"context"
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"time"
 )
 
+// ErrPasswordResetThrottled 表示該使用者在節流時間內已建立過重設 token
+var ErrPasswordResetThrottled = errors.New("password reset requested too recently")
+
 type PasswordReset struct {
 	ID        int
 	UserID    int
@@ -16,7 +21,14 @@ type PasswordReset struct {
 	CreatedAt time.Time
 }
 
-func CreatePasswordReset(database *sql.DB, userID int) (*PasswordReset, error) {
+// CreatePasswordReset 建立新的重設 token，並在同一個 transaction 中把該使用者先前未使用的 token 標記為已使用，
+// 確保只有最新寄出的連結有效。throttle 時間內已建立過 token 時回傳 ErrPasswordResetThrottled（0 代表不節流）
+func CreatePasswordReset(database *sql.DB, userID int, throttle time.Duration) (*PasswordReset, error) {
+	return CreatePasswordResetContext(context.Background(), database, userID, throttle)
+}
+
+// CreatePasswordResetContext 與 CreatePasswordReset 相同，ctx 取消或逾時時中止查詢
+func CreatePasswordResetContext(ctx context.Context, database *sql.DB, userID int, throttle time.Duration) (*PasswordReset, error) {
 	token, err := generateResetToken()
 	if err != nil {
 		return nil, err
@@ -24,7 +36,41 @@ func CreatePasswordReset(database *sql.DB, userID int) (*PasswordReset, error) {
 
 	expiresAt := time.Now().Add(time.Hour * 1) // 1 hour expiration
 
-	_, err = database.Exec(
+	transaction, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer transaction.Rollback()
+
+	if throttle > 0 {
+		// 鎖定使用者資料列，避免同時送出的請求都通過節流檢查
+		var lockedID int
+		if err = transaction.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Scan(&lockedID); err != nil {
+			return nil, err
+		}
+
+		var recent bool
+		err = transaction.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM password_resets WHERE user_id = ? AND created_at > NOW() - INTERVAL ? SECOND)",
+			userID, int64(throttle.Seconds()),
+		).Scan(&recent)
+		if err != nil {
+			return nil, err
+		}
+		if recent {
+			return nil, ErrPasswordResetThrottled
+		}
+	}
+
+	_, err = transaction.ExecContext(ctx,
+		"UPDATE password_resets SET used = TRUE WHERE user_id = ? AND used = FALSE",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = transaction.ExecContext(ctx,
 		"INSERT INTO password_resets (user_id, token, expires_at) VALUES (?, ?, ?)",
 		userID, token, expiresAt,
 	)
@@ -32,6 +78,10 @@ func CreatePasswordReset(database *sql.DB, userID int) (*PasswordReset, error) {
 		return nil, err
 	}
 
+	if err = transaction.Commit(); err != nil {
+		return nil, err
+	}
+
 	return &PasswordReset{
 		UserID:    userID,
 		Token:     token,
@@ -42,7 +92,12 @@ func CreatePasswordReset(database *sql.DB, userID int) (*PasswordReset, error) {
 }
 
 func GetPasswordResetByToken(database *sql.DB, token string) (*PasswordReset, error) {
-	row := database.QueryRow(
+	return GetPasswordResetByTokenContext(context.Background(), database, token)
+}
+
+// GetPasswordResetByTokenContext 與 GetPasswordResetByToken 相同，ctx 取消或逾時時中止查詢
+func GetPasswordResetByTokenContext(ctx context.Context, database *sql.DB, token string) (*PasswordReset, error) {
+	row := database.QueryRowContext(ctx,
 		"SELECT id, user_id, token, expires_at, used, created_at FROM password_resets WHERE token = ? AND used = FALSE AND expires_at > NOW()",
 		token,
 	)
@@ -56,18 +111,32 @@ func GetPasswordResetByToken(database *sql.DB, token string) (*PasswordReset, er
 }
 
 func MarkPasswordResetAsUsed(database *sql.DB, token string) error {
-	_, err := database.Exec(
+	return MarkPasswordResetAsUsedContext(context.Background(), database, token)
+}
+
+// MarkPasswordResetAsUsedContext 與 MarkPasswordResetAsUsed 相同，ctx 取消或逾時時中止查詢
+func MarkPasswordResetAsUsedContext(ctx context.Context, database *sql.DB, token string) error {
+	_, err := database.ExecContext(ctx,
 		"UPDATE password_resets SET used = TRUE WHERE token = ?",
 		token,
 	)
 	return err
 }
 
-func CleanupExpiredPasswordResets(database *sql.DB) error {
-	_, err := database.Exec(
+// CleanupExpiredPasswordResets 刪除過期或已使用的 token，回傳刪除筆數
+func CleanupExpiredPasswordResets(database *sql.DB) (int64, error) {
+	return CleanupExpiredPasswordResetsContext(context.Background(), database)
+}
+
+// CleanupExpiredPasswordResetsContext 與 CleanupExpiredPasswordResets 相同，ctx 取消或逾時時中止查詢
+func CleanupExpiredPasswordResetsContext(ctx context.Context, database *sql.DB) (int64, error) {
+	result, err := database.ExecContext(ctx,
 		"DELETE FROM password_resets WHERE expires_at < NOW() OR used = TRUE",
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 func generateResetToken() (string, error) {
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// capturedArg 比對任何參數並記下其值，用來取得 INSERT 時產生的 token
+type capturedArg struct {
+	value driver.Value
+}
+
+func (arg *capturedArg) Match(value driver.Value) bool {
+	arg.value = value
+	return true
+}
+
+func TestSecondPasswordResetInvalidatesFirst(t *testing.T) {
+	database, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer database.Close()
+
+	invalidate := "UPDATE password_resets SET used = TRUE WHERE user_id = \\? AND used = FALSE"
+	firstToken, secondToken := &capturedArg{}, &capturedArg{}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(invalidate).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO password_resets").WithArgs(7, firstToken, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// 第二次申請會先把第一個 token 標記為已使用，再寫入新的 token
+	mock.ExpectBegin()
+	mock.ExpectExec(invalidate).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO password_resets").WithArgs(7, secondToken, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	first, err := CreatePasswordReset(database, 7, 0)
+	if err != nil {
+		t.Fatalf("first CreatePasswordReset: %v", err)
+	}
+	second, err := CreatePasswordReset(database, 7, 0)
+	if err != nil {
+		t.Fatalf("second CreatePasswordReset: %v", err)
+	}
+	if first.Token == second.Token {
+		t.Fatal("expected a new token for the second reset")
+	}
+	if firstToken.value != first.Token || secondToken.value != second.Token {
+		t.Fatal("returned tokens do not match the inserted tokens")
+	}
+
+	lookup := "SELECT id, user_id, token, expires_at, used, created_at FROM password_resets WHERE token = \\? AND used = FALSE AND expires_at > NOW\\(\\)"
+	columns := []string{"id", "user_id", "token", "expires_at", "used", "created_at"}
+	// 第一個 token 已被標記為 used，查詢條件 used = FALSE 不會再找到它
+	mock.ExpectQuery(lookup).WithArgs(first.Token).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(lookup).WithArgs(second.Token).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 7, second.Token, time.Now().Add(time.Hour), false, time.Now()))
+
+	if _, err := GetPasswordResetByToken(database, first.Token); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected the first token to be invalid, got %v", err)
+	}
+	reset, err := GetPasswordResetByToken(database, second.Token)
+	if err != nil {
+		t.Fatalf("expected the second token to be valid, got %v", err)
+	}
+	if reset.UserID != 7 {
+		t.Fatalf("expected user 7, got %d", reset.UserID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type UserProfile struct {
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	// 最後一次成功登入的時間，從未登入時為 null
+	LastLoginAt *time.Time `json:"last_login_at"`
+	// 舊版回應就有的欄位，保留以維持相容
+	Message string `json:"message" example:"You are authenticated!"`
+}
+
+// UserProfileV2 為 v2 的個人資訊，拿掉 Message 並加上驗證狀態與計畫統計
+type UserProfileV2 struct {
+	UserID      int          `json:"user_id"`
+	Username    string       `json:"username"`
+	Email       string       `json:"email"`
+	IsVerified  bool         `json:"is_verified"`
+	CreatedAt   time.Time    `json:"created_at"`
+	LastLoginAt *time.Time   `json:"last_login_at"`
+	Stats       ProfileStats `json:"stats"`
+}
+
+// ProfileStats 為未封存的區塊數與未刪除的任務數
+type ProfileStats struct {
+	Sections       int `json:"sections"`
+	Tasks          int `json:"tasks"`
+	CompletedTasks int `json:"completed_tasks"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrRefreshTokenInvalid 表示 refresh token 不存在、已過期或已被使用過
+var ErrRefreshTokenInvalid = errors.New("refresh token invalid or already used")
+
+// Querier 為 *sql.DB 與 *sql.Tx 共同的方法，讓 refresh token 的操作可以放進同一個 transaction
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	Token     string
+	ExpiresAt time.Time
+	Used      bool
+	CreatedAt time.Time
+}
+
+func CreateRefreshToken(database Querier, userID int, ttl time.Duration) (*RefreshToken, error) {
+	token, err := generateResetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+
+	_, err = database.Exec(
+		"INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES (?, ?, ?)",
+		userID, token, expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefreshToken{
+		UserID:    userID,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		Used:      false,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// ConsumeRefreshToken 將有效的 refresh token 標記為已使用並回傳其 user_id。
+// 以單一條件式 UPDATE 完成，同一個 token 的並行請求只會有一個成功。
+// token 無效、過期或已使用時回傳 ErrRefreshTokenInvalid，其他錯誤為 DB 錯誤。
+func ConsumeRefreshToken(database Querier, token string) (int, error) {
+	result, err := database.Exec(
+		"UPDATE refresh_tokens SET used = TRUE WHERE token = ? AND used = FALSE AND expires_at > NOW()",
+		token,
+	)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected != 1 {
+		return 0, ErrRefreshTokenInvalid
+	}
+
+	var userID int
+	err = database.QueryRow("SELECT user_id FROM refresh_tokens WHERE token = ?", token).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
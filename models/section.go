@@ -6,14 +6,80 @@ type UpdateSectionInput struct {
 	Title string `json:"title" binding:"required"`
 }
 
+// ReorderSectionsInput 為依新順序排列的區塊 ID，必須剛好包含所有未封存的區塊
+type ReorderSectionsInput struct {
+	SectionIDs []int64 `json:"section_ids" binding:"required,min=1" example:"3,1,2"`
+}
+
 type CreateSectionInput struct {
 	Title string `json:"title" binding:"required"`
+	// 前端樂觀更新用的暫時 ID，會原封不動回傳
+	ClientID string `json:"client_id,omitempty"`
+}
+
+type SetSectionClosedInput struct {
+	IsClosed *bool `json:"is_closed" binding:"required"`
 }
 
 type Section struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	SortOrder int       `json:"sort_order"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int64     `json:"id"`
+	Title      string    `json:"title"`
+	SortOrder  int       `json:"sort_order"`
+	IsClosed   bool      `json:"is_closed"`
+	IsArchived bool      `json:"is_archived"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type SectionForecast struct {
+	SectionID               int64   `json:"section_id"`
+	RemainingTasks          int     `json:"remaining_tasks"`
+	WindowDays              int     `json:"window_days"`
+	CompletedInWindow       int     `json:"completed_in_window"`
+	VelocityPerDay          float64 `json:"velocity_per_day"`
+	EstimatedDays           *int    `json:"estimated_days"`
+	EstimatedCompletionDate *string `json:"estimated_completion_date"`
+}
+
+// PriorityCounts 為各優先度的任務數，沒有任務的優先度為 0
+type PriorityCounts struct {
+	Low    int `json:"low"`
+	Medium int `json:"medium"`
+	High   int `json:"high"`
+}
+
+// CompletionCounts 為已完成與未完成的任務數
+type CompletionCounts struct {
+	Completed  int `json:"completed"`
+	Incomplete int `json:"incomplete"`
+}
+
+// SectionPriorityBreakdown 為區塊內未刪除任務依優先度與完成狀態的分布
+type SectionPriorityBreakdown struct {
+	SectionID    int64            `json:"section_id"`
+	Total        int              `json:"total"`
+	ByPriority   PriorityCounts   `json:"by_priority"`
+	ByCompletion CompletionCounts `json:"by_completion"`
+}
+
+type SectionStats struct {
+	SectionID  int64   `json:"section_id"`
+	Title      string  `json:"title"`
+	Total      int     `json:"total"`
+	Completed  int     `json:"completed"`
+	Percentage float64 `json:"percentage"`
+}
+
+// PlanStats 為所有未封存區塊的完成統計，Total 與 Completed 為各區塊的合計
+type PlanStats struct {
+	Sections   []SectionStats `json:"sections"`
+	Total      int            `json:"total"`
+	Completed  int            `json:"completed"`
+	Percentage float64        `json:"percentage"`
+}
+
+type RecentSection struct {
+	Section
+	LastActiveAt time.Time `json:"last_active_at"`
 }
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	// 每個區塊最多保留的快照數，超過時刪除最舊的
+	MaxSnapshotsPerSection = 20
+	// 快照保留期限，過期的快照會在建立新快照時清除
+	SnapshotRetention = 30 * 24 * time.Hour
+)
+
+type SectionSnapshot struct {
+	ID        int64     `json:"id"`
+	SectionID int64     `json:"section_id"`
+	TaskCount int       `json:"task_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SnapshotTask 為快照中保存的單一任務內容（含排序），還原時依此重建任務
+type SnapshotTask struct {
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	IsCompleted   bool       `json:"is_completed"`
+	Priority      string     `json:"priority"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	SortOrder     int        `json:"sort_order"`
+	ExternalRef   *string    `json:"external_ref,omitempty"`
+	DueDate       *time.Time `json:"due_date,omitempty"`
+	DeferredUntil *time.Time `json:"deferred_until,omitempty"`
+	Labels        []string   `json:"labels,omitempty"`
+}
@@ -1,10 +1,32 @@
 package models
 
+import "time"
+
 type SectionWithTasks struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	SortOrder int    `json:"sort_order"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	Tasks     []Task `json:"tasks"`
+	ID         int64  `json:"id"`
+	Title      string `json:"title"`
+	SortOrder  int    `json:"sort_order"`
+	IsClosed   bool   `json:"is_closed"`
+	IsArchived bool   `json:"is_archived"`
+	Version    int    `json:"version"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+	Tasks      []Task `json:"tasks"`
+}
+
+// PlanExportVersion 為匯出格式的版本，格式改變時遞增，匯入時依版本轉換
+const PlanExportVersion = 1
+
+// PlanExport 為 /plans/export 的備份格式
+type PlanExport struct {
+	Version    int                `json:"version" example:"1"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Sections   []SectionWithTasks `json:"sections"`
+}
+
+// PlanImportResult 為匯入後建立的區塊與任務數
+type PlanImportResult struct {
+	Mode     string `json:"mode" example:"append"`
+	Sections int    `json:"sections"`
+	Tasks    int    `json:"tasks"`
 }
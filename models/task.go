@@ -1,14 +1,64 @@
 package models
 
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
 type Task struct {
 	ID          int64  `json:"id"`
 	SectionID   int64  `json:"section_id"`
 	Title       string `json:"title"`
 	Content     string `json:"content"`
 	IsCompleted bool   `json:"is_completed"`
+	Priority    string `json:"priority" enums:"low,medium,high" example:"medium"`
 	SortOrder   int    `json:"sort_order"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
+	// 外部系統（例如 GitHub issue）的對應 ID，同一使用者內不可重複
+	ExternalRef *string `json:"external_ref"`
+	// 到期時間（UTC），沒有到期日時為 null
+	DueDate *time.Time `json:"due_date"`
+	// 延後到此時間（UTC）之前，任務不會出現在預設列表中
+	DeferredUntil *time.Time `json:"deferred_until"`
+	// 樂觀鎖版本，排序或移動時遞增；批次排序時需帶回最後讀到的值
+	Version int `json:"version"`
+	// 軟刪除時間，只有 include_deleted 時才會出現
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+const DefaultTaskPriority = "medium"
+
+// TaskTrashRetention 為軟刪除任務保留的時間，超過後由 PurgeDeletedTasks 永久刪除
+const TaskTrashRetention = 30 * 24 * time.Hour
+
+// ReleaseTrashedExternalRef 清除使用者垃圾桶中佔用 externalRef 的任務的 external_ref。
+// external_ref 只在未刪除的任務之間不可重複，寫入 external_ref 前先呼叫，避免已刪除的任務擋住新任務；externalRef 為 nil 時不做任何事
+func ReleaseTrashedExternalRef(querier Querier, userID int64, externalRef *string) error {
+	if externalRef == nil {
+		return nil
+	}
+	_, err := querier.Exec(
+		"UPDATE tasks SET external_ref = NULL WHERE user_id = ? AND external_ref = ? AND deleted_at IS NOT NULL",
+		userID, *externalRef,
+	)
+	return err
+}
+
+// PurgeDeletedTasks 永久刪除軟刪除超過 olderThan 的任務，回傳刪除筆數
+func PurgeDeletedTasks(database *sql.DB, olderThan time.Duration) (int64, error) {
+	return PurgeDeletedTasksContext(context.Background(), database, olderThan)
+}
+
+// PurgeDeletedTasksContext 與 PurgeDeletedTasks 相同，ctx 取消或逾時時中止查詢
+func PurgeDeletedTasksContext(ctx context.Context, database *sql.DB, olderThan time.Duration) (int64, error) {
+	cutoff := time.Now().UTC().Add(-olderThan)
+	result, err := database.ExecContext(ctx, "DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 type CreateTaskInput struct {
@@ -16,10 +66,54 @@ type CreateTaskInput struct {
 	Title       string `json:"title" binding:"required"`
 	Content     string `json:"content" binding:"required"`
 	IsCompleted bool   `json:"is_completed"`
+	// 未提供時為 medium
+	Priority string `json:"priority,omitempty" binding:"omitempty,oneof=low medium high" enums:"low,medium,high"`
+	// 前端樂觀更新用的暫時 ID，會原封不動回傳
+	ClientID    string  `json:"client_id,omitempty"`
+	ExternalRef *string `json:"external_ref,omitempty" binding:"omitempty,max=255"`
+	// RFC 3339 時間（含時區），例如 2026-01-02T09:00:00+08:00
+	DueDate       *time.Time `json:"due_date,omitempty"`
+	DeferredUntil *time.Time `json:"deferred_until,omitempty"`
 }
 
+// UpdateTaskInput 只會更新有出現在請求中的欄位（nil 代表未提供）
 type UpdateTaskInput struct {
-	Title       string `json:"title"`
-	Content     string `json:"content"`
-	IsCompleted bool   `json:"is_completed"`
+	Title       *string `json:"title"`
+	Content     *string `json:"content"`
+	IsCompleted *bool   `json:"is_completed"`
+	Priority    *string `json:"priority" binding:"omitempty,oneof=low medium high" enums:"low,medium,high"`
+	// 空字串代表清除 external_ref
+	ExternalRef *string `json:"external_ref" binding:"omitempty,max=255"`
+	// 帶 null 代表清除到期日
+	DueDate OptionalTime `json:"due_date" swaggertype:"string" format:"date-time"`
+	// 清除延後請改用 PUT /plans/tasks/{id}/defer 並帶 null
+	DeferredUntil *time.Time `json:"deferred_until"`
+}
+
+type SetTaskCompletedInput struct {
+	IsCompleted *bool `json:"is_completed" binding:"required"`
+}
+
+// MoveTaskInput 將任務移到 section_id 的第 sort_order 個位置（1 起算，未提供或超出範圍則放到最後）
+type MoveTaskInput struct {
+	SectionID int64 `json:"section_id" binding:"required"`
+	SortOrder *int  `json:"sort_order"`
+}
+
+// DeferTaskInput 的 deferred_until 為 null 時代表取消延後
+type DeferTaskInput struct {
+	DeferredUntil *time.Time `json:"deferred_until"`
+}
+
+type TaskPage struct {
+	Items    interface{} `json:"items"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Total    int         `json:"total"`
+}
+
+// TaskSearchResult 為搜尋結果與今日任務清單的項目，附上任務所屬區塊的標題
+type TaskSearchResult struct {
+	Task
+	SectionTitle string `json:"section_title"`
 }
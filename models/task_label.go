@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+type TaskLabelInput struct {
+	Label string `json:"label" binding:"required,max=50" example:"work"`
+}
+
+type TaskLabels struct {
+	TaskID int64    `json:"task_id"`
+	Labels []string `json:"labels"`
+}
+
+// AttachTaskLabel 為任務加上標籤，(task_id, label) 為主鍵，重複加上不會產生第二筆
+func AttachTaskLabel(querier Querier, taskID int64, label string) error {
+	_, err := querier.Exec("INSERT IGNORE INTO task_labels (task_id, label) VALUES (?, ?)", taskID, label)
+	return err
+}
+
+// NormalizeTaskLabel 去除前後空白並轉為小寫，讓 "Work" 與 "work" 視為同一個標籤
+func NormalizeTaskLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// MergeDuplicateTaskLabelsContext 把既有標籤一次轉為 NormalizeTaskLabel 的形式：同一任務中正規化後相同的標籤只保留一筆，
+// 其餘刪除後再改寫為小寫。以 BINARY 比較，不受資料表 collation 是否區分大小寫影響；回傳刪除的重複標籤數
+func MergeDuplicateTaskLabelsContext(ctx context.Context, database *sql.DB) (int64, error) {
+	transaction, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer transaction.Rollback()
+
+	result, err := transaction.ExecContext(ctx, `
+		DELETE l FROM task_labels l
+		JOIN task_labels k
+		  ON k.task_id = l.task_id
+		 AND BINARY LOWER(TRIM(k.label)) = BINARY LOWER(TRIM(l.label))
+		 AND BINARY k.label < BINARY l.label`)
+	if err != nil {
+		return 0, err
+	}
+	merged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// 每組只剩一筆，改寫為正規化形式不會違反主鍵
+	_, err = transaction.ExecContext(ctx,
+		"UPDATE task_labels SET label = LOWER(TRIM(label)) WHERE BINARY label <> BINARY LOWER(TRIM(label))")
+	if err != nil {
+		return 0, err
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return 0, err
+	}
+	return merged, nil
+}
+
+// DetachTaskLabel 移除任務的標籤，回傳是否真的有刪除
+func DetachTaskLabel(database *sql.DB, taskID int64, label string) (bool, error) {
+	result, err := database.Exec("DELETE FROM task_labels WHERE task_id = ? AND label = ?", taskID, label)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// GetTaskLabels 依字母順序回傳任務的所有標籤
+func GetTaskLabels(database *sql.DB, taskID int64) ([]string, error) {
+	rows, err := database.Query("SELECT label FROM task_labels WHERE task_id = ? ORDER BY label ASC", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []string{}
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
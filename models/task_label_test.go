@@ -0,0 +1,33 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMergeDuplicateTaskLabelsDeletesDuplicatesBeforeLowercasing(t *testing.T) {
+	database, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer database.Close()
+
+	// 先刪除正規化後重複的標籤，剩下的才改寫為小寫，避免 UPDATE 違反 (task_id, label) 主鍵
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE l FROM task_labels l\\s+JOIN task_labels k").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE task_labels SET label = LOWER\\(TRIM\\(label\\)\\)").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	merged, err := MergeDuplicateTaskLabelsContext(context.Background(), database)
+	if err != nil {
+		t.Fatalf("MergeDuplicateTaskLabelsContext: %v", err)
+	}
+	if merged != 3 {
+		t.Fatalf("expected 3 merged labels, got %d", merged)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
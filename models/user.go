@@ -2,18 +2,31 @@ package models
 
 import (
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 type UserRegisterInput struct {
-	Username string `json:"username" example:"walter"`
-	Email    string `json:"email" example:"w@w.com"`
-	Password string `json:"password" example:"123456"`
+	Username string `json:"username" binding:"required" example:"walter"`
+	Email    string `json:"email" binding:"required,email" example:"w@w.com"`
+	Password string `json:"password" binding:"required,min=8" minLength:"8" example:"12345678"`
+}
+
+type UpdateUserInput struct {
+	Username string `json:"username" binding:"required,max=50" example:"walter"`
+	Email    string `json:"email" binding:"required,email,max=100" example:"w@w.com"`
+}
+
+type DeleteUserInput struct {
+	Password string `json:"password" binding:"required" example:"12345678"`
 }
 
 type UserLoginInput struct {
 	Email    string `json:"email" example:"w@w.com"`
-	Password string `json:"password" example:"123456"`
+	Password string `json:"password" example:"12345678"`
 }
 
 type User struct {
@@ -21,22 +34,62 @@ type User struct {
 	Username     string
 	Email        string
 	PasswordHash string
-	CreatedAt    time.Time
+	IsVerified   bool
+	// 最後一次成功登入的時間，從未登入時為 nil
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
 }
 
+var (
+	ErrDuplicateEmail    = errors.New("email already registered")
+	ErrDuplicateUsername = errors.New("username already exists")
+)
+
+// mysqlDuplicateEntry 為 MySQL 違反唯一索引的錯誤代碼（ER_DUP_ENTRY）
+const mysqlDuplicateEntry = 1062
+
+// CreateUser 新增使用者。依賴 users.email 與 users.username 的唯一索引，
+// 重複時回傳 ErrDuplicateEmail 或 ErrDuplicateUsername。
 func CreateUser(database *sql.DB, user *User) error {
-	_, error := database.Exec(
+	result, error := database.Exec(
 		"INSERT INTO users (username, email, password_hash) VALUES (?, ?, ?)",
 		user.Username, user.Email, user.PasswordHash,
 	)
-	return error
+	if error != nil {
+		return mapDuplicateUserError(error)
+	}
+
+	identifier, error := result.LastInsertId()
+	if error != nil {
+		return error
+	}
+	user.ID = int(identifier)
+	return nil
+}
+
+// IsDuplicateEntry 判斷錯誤是否為 MySQL 違反唯一索引
+func IsDuplicateEntry(err error) bool {
+	var mysqlError *mysql.MySQLError
+	return errors.As(err, &mysqlError) && mysqlError.Number == mysqlDuplicateEntry
+}
+
+func mapDuplicateUserError(err error) error {
+	var mysqlError *mysql.MySQLError
+	if !errors.As(err, &mysqlError) || mysqlError.Number != mysqlDuplicateEntry {
+		return err
+	}
+	// 錯誤訊息格式：Duplicate entry 'x' for key 'users.email'
+	if strings.Contains(mysqlError.Message, "username") {
+		return ErrDuplicateUsername
+	}
+	return ErrDuplicateEmail
 }
 
 func GetUserByEmail(database *sql.DB, email string) (*User, error) {
-	row := database.QueryRow("SELECT id, username, email, password_hash, created_at FROM users WHERE email = ?", email)
+	row := database.QueryRow("SELECT id, username, email, password_hash, is_verified, last_login_at, created_at FROM users WHERE email = ?", email)
 
 	var user User
-	error := row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	error := row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.IsVerified, &user.LastLoginAt, &user.CreatedAt)
 	if error != nil {
 		return nil, error
 	}
@@ -44,16 +97,58 @@ func GetUserByEmail(database *sql.DB, email string) (*User, error) {
 }
 
 func GetUserByID(database *sql.DB, id int) (*User, error) {
-	row := database.QueryRow("SELECT id, username, email, password_hash, created_at FROM users WHERE id = ?", id)
+	row := database.QueryRow("SELECT id, username, email, password_hash, is_verified, last_login_at, created_at FROM users WHERE id = ?", id)
 
 	var user User
-	error := row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	error := row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.IsVerified, &user.LastLoginAt, &user.CreatedAt)
 	if error != nil {
 		return nil, error
 	}
 	return &user, nil
 }
 
+// UpdateUser 更新使用者的 username、email 與驗證狀態，
+// 與 CreateUser 相同，重複時回傳 ErrDuplicateEmail 或 ErrDuplicateUsername。
+func UpdateUser(database *sql.DB, user *User) error {
+	_, error := database.Exec(
+		"UPDATE users SET username = ?, email = ?, is_verified = ? WHERE id = ?",
+		user.Username, user.Email, user.IsVerified, user.ID,
+	)
+	return mapDuplicateUserError(error)
+}
+
+// DeleteUser 在同一個 transaction 中刪除使用者與其所有資料。
+// sections / tasks 沒有指向 users 的外鍵，必須先刪子表再刪 users，避免留下孤兒資料。
+func DeleteUser(database *sql.DB, userID int) error {
+	transaction, error := database.Begin()
+	if error != nil {
+		return error
+	}
+	defer transaction.Rollback()
+
+	statements := []string{
+		"DELETE FROM tasks WHERE user_id = ?",
+		"DELETE FROM section_snapshots WHERE user_id = ?",
+		"DELETE FROM sections WHERE user_id = ?",
+		"DELETE FROM password_resets WHERE user_id = ?",
+		"DELETE FROM refresh_tokens WHERE user_id = ?",
+		"DELETE FROM email_verifications WHERE user_id = ?",
+		"DELETE FROM users WHERE id = ?",
+	}
+	for _, statement := range statements {
+		if _, error := transaction.Exec(statement, userID); error != nil {
+			return error
+		}
+	}
+	return transaction.Commit()
+}
+
+// TouchLastLogin 將使用者的最後登入時間更新為現在
+func TouchLastLogin(database *sql.DB, userID int) error {
+	_, error := database.Exec("UPDATE users SET last_login_at = CURRENT_TIMESTAMP WHERE id = ?", userID)
+	return error
+}
+
 func UpdateUserPassword(database *sql.DB, userID int, newPasswordHash string) error {
 	_, error := database.Exec(
 		"UPDATE users SET password_hash = ? WHERE id = ?",
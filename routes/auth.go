@@ -2,18 +2,29 @@ package routes
 
 import (
 	"database/sql"
+	"log"
 
 	"github.com/gin-gonic/gin"
+	"github.com/Walter1412/micro-backend/config"
 	"github.com/Walter1412/micro-backend/handlers"
+	"github.com/Walter1412/micro-backend/middlewares"
 	"github.com/Walter1412/micro-backend/services"
 )
 
-func RegisterAuthRoutes(router *gin.RouterGroup, database *sql.DB, emailService *services.EmailService) {
-	router.POST("/register", handlers.Register(database))
-	router.POST("/login", handlers.Login(database))
-	router.POST("/forgot-password", handlers.ForgotPassword(database, emailService))
-	router.POST("/reset-password", handlers.ResetPassword(database))
+func RegisterAuthRoutes(router *gin.RouterGroup, database *sql.DB, emailService services.EmailSender, cfg *config.Config, userCache *middlewares.UserCache) {
+	loginLimiter := services.NewLoginLimiter(cfg.Server.LoginMaxFailures, cfg.Server.LoginFailureWindow)
+
+	router.POST("/register", handlers.Register(database, emailService, cfg))
+	router.GET("/verify-email", handlers.VerifyEmail(database))
+	router.POST("/resend-verification", handlers.ResendVerification(database, emailService, cfg))
+	router.POST("/login", handlers.Login(database, cfg, loginLimiter))
+	router.POST("/refresh", handlers.Refresh(database, cfg))
+	router.POST("/forgot-password", handlers.ForgotPassword(database, emailService, cfg))
+	router.POST("/reset-password", handlers.ResetPassword(database, cfg, userCache))
 	
-	// 開發測試端點
-	router.GET("/dev/latest-token", handlers.GetLatestToken(database))
+	// 開發測試端點會洩漏有效的重設 token，只在明確開啟時註冊，其他環境一律 404
+	if cfg.Server.DevEndpointsEnabled() {
+		log.Printf("⚠️⚠️⚠️ Dev endpoints are ENABLED (GET /dev/latest-token exposes password reset tokens), never use this in production")
+		router.GET("/dev/latest-token", handlers.GetLatestToken(database))
+	}
 }
\ No newline at end of file
@@ -4,28 +4,70 @@ import (
 	"database/sql"
 
 	"github.com/gin-gonic/gin"
+	"github.com/Walter1412/micro-backend/config"
 	"github.com/Walter1412/micro-backend/handlers"
 )
 
-func RegisterPlanRoutes(router *gin.RouterGroup, database *sql.DB) {
+func RegisterPlanRoutes(router *gin.RouterGroup, database *sql.DB, cfg *config.Config) {
 	plans := router.Group("/plans")
 	{
 		sections := plans.Group("/sections")
 		{
 			sections.GET("", handlers.GetSections(database))
+			sections.HEAD("", handlers.CountSections(database))
 			sections.POST("", handlers.CreateSection(database))
+			sections.POST("/bulk", handlers.BulkCreateSections(database))
+			sections.GET("/stats", handlers.GetSectionsStats(database))
+			sections.GET("/recent", handlers.GetRecentSections(database))
+			sections.PUT("/reorder", handlers.ReorderSections(database))
+			sections.GET("/:id", handlers.GetSection(database))
 			sections.DELETE("/:id", handlers.DeleteSection(database))
 			sections.PUT("/:id", handlers.UpdateSection(database))
+			sections.PUT("/:id/closed", handlers.SetSectionClosed(database))
+			sections.PUT("/:id/archive", handlers.ArchiveSection(database))
+			sections.PUT("/:id/unarchive", handlers.UnarchiveSection(database))
+			sections.PUT("/:id/complete-all", handlers.CompleteAllTasks(database))
+			sections.PUT("/:id/uncomplete-all", handlers.UncompleteAllTasks(database))
+			sections.PATCH("/:id/after/:targetId", handlers.MoveSectionAfter(database))
+			sections.PATCH("/:id/before/:targetId", handlers.MoveSectionBefore(database))
+			sections.POST("/:id/duplicate", handlers.DuplicateSection(database))
+			sections.GET("/:id/forecast", handlers.GetSectionForecast(database))
+			sections.GET("/:id/priority-breakdown", handlers.GetSectionPriorityBreakdown(database))
+			sections.GET("/:id/snapshots", handlers.GetSectionSnapshots(database))
+			sections.POST("/:id/snapshots", handlers.CreateSectionSnapshot(database))
+			sections.POST("/:id/restore-snapshot/:snapshotId", handlers.RestoreSectionSnapshot(database))
 		}
 
 		tasks := plans.Group("/tasks")
 		{
+			tasks.GET("", handlers.GetTasks(database))
+			tasks.HEAD("", handlers.CountTasks(database))
 			tasks.POST("", handlers.CreateTask(database))
+			tasks.POST("/batch", handlers.CreateTasksBatch(database))
+			tasks.GET("/upcoming", handlers.GetUpcomingTasks(database))
+			tasks.GET("/today", handlers.GetTodayTasks(database))
+			tasks.GET("/export.csv", handlers.ExportTasksCSV(database))
+			tasks.GET("/trash", handlers.GetTrashedTasks(database))
+			tasks.HEAD("/trash", handlers.CountTrashedTasks(database))
+			tasks.GET("/by-ref/:ref", handlers.GetTaskByExternalRef(database))
+			tasks.GET("/:id", handlers.GetTask(database))
 			tasks.PUT("/:id", handlers.UpdateTask(database))
 			tasks.DELETE("/:id", handlers.DeleteTask(database))
+			tasks.PATCH("/:id/complete", handlers.SetTaskCompleted(database))
+			tasks.PUT("/:id/move", handlers.MoveTask(database))
+			tasks.PUT("/:id/defer", handlers.DeferTask(database))
+			tasks.POST("/:id/restore", handlers.RestoreTask(database))
+			tasks.POST("/:id/labels", handlers.AttachTaskLabel(database, cfg))
+			tasks.DELETE("/:id/labels/:label", handlers.DetachTaskLabel(database, cfg))
 		}
 
+		plans.GET("/search", handlers.SearchTasks(database))
+		plans.GET("/next", handlers.GetNextTask(database))
+		plans.GET("/stats", handlers.GetPlanStats(database))
+		plans.GET("/export", handlers.ExportPlans(database))
+		plans.POST("/import", handlers.ImportPlans(database))
 		plans.GET("/sections-with-tasks", handlers.GetSectionsWithTasks(database))
 		plans.PUT("/sections-with-tasks", handlers.UpdateSectionsWithTasks(database))
+		plans.POST("/batch", handlers.ExecuteBatch(database))
 	}
 }
\ No newline at end of file
@@ -1,10 +1,19 @@
 package routes
 
 import (
+	"database/sql"
+
 	"github.com/gin-gonic/gin"
 	"github.com/Walter1412/micro-backend/handlers"
+	"github.com/Walter1412/micro-backend/middlewares"
+	"github.com/Walter1412/micro-backend/services"
 )
 
-func RegisterProfileRoutes(router *gin.RouterGroup) {
-	router.GET("/profile", handlers.Profile())
+func RegisterProfileRoutes(router *gin.RouterGroup, database *sql.DB, emailService services.EmailSender, userCache *middlewares.UserCache) {
+	// 需要完整使用者資料的路由才掛 LoadUserMiddleware
+	withUser := router.Group("", middlewares.LoadUserMiddleware(userCache))
+	withUser.GET("/profile", handlers.Profile())
+	withUser.PUT("/profile", handlers.UpdateProfile(database, emailService, userCache))
+	withUser.DELETE("/profile", handlers.DeleteAccount(database, userCache))
+	router.GET("/profile/footprint", handlers.GetFootprint(database))
 }
\ No newline at end of file
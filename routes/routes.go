@@ -2,39 +2,102 @@ package routes
 
 import (
 	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/Walter1412/micro-backend/config"
+	"github.com/Walter1412/micro-backend/handlers"
 	"github.com/Walter1412/micro-backend/middlewares"
 	"github.com/Walter1412/micro-backend/services"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"golang.org/x/time/rate"
 )
 
+// currentUserCacheTTL 為 LoadUserMiddleware 快取使用者資料的時間，修改使用者的 handler 會主動清除
+const currentUserCacheTTL = 30 * time.Second
+
 func RegisterRoutes(router *gin.Engine, database *sql.DB, cfg *config.Config) {
 	// Initialize services
 	emailService := services.NewEmailService(cfg.Email)
+	userCache := middlewares.NewUserCache(database, currentUserCacheTTL)
+
+	// Panic recovery 最先掛上，包住所有中介層與 handler
+	router.Use(middlewares.RecoveryMiddleware())
+
+	// Request ID 與 request logging 接著掛上，才能記錄被後面中介層擋下的請求
+	router.Use(middlewares.RequestIDMiddleware())
+	router.Use(middlewares.RequestLoggingMiddleware(cfg.Server.LogFormat))
 
 	// CORS middleware
-	router.Use(middlewares.CORSMiddleware())
+	router.Use(middlewares.CORSMiddleware(cfg))
 	
-	// Rate limiting middleware
-	router.Use(middlewares.RateLimitMiddleware())
+	// Rate limiting middleware（全域限制在外層，再依 IP 限制）
+	router.Use(middlewares.RateLimitMiddleware(rate.NewLimiter(rate.Limit(cfg.RateLimit.GlobalRPS), cfg.RateLimit.GlobalBurst), cfg.RateLimit.Whitelist))
+	router.Use(middlewares.IPRateLimitMiddleware(middlewares.NewIPRateLimiter(cfg.RateLimit.RPS, cfg.RateLimit.Burst, cfg.RateLimit.IdleTTL), cfg.RateLimit.Whitelist))
+
+	// Request body size limit（在解壓縮之前限制原始大小，批次與匯入端點放寬上限）
+	bulkBodyLimits := map[string]int64{}
+	for _, path := range []string{"/plans/batch", "/plans/import", "/plans/sections-with-tasks", "/plans/tasks/batch", "/plans/sections/bulk"} {
+		bulkBodyLimits[cfg.Server.APIBasePath+path] = cfg.Server.MaxBulkBodyBytes
+	}
+	router.Use(middlewares.BodyLimitMiddleware(cfg.Server.MaxBodyBytes, bulkBodyLimits))
+
+	// Request body decompression (gzip / deflate)，解壓後的大小同樣受上面的路由上限限制
+	router.Use(middlewares.DecompressMiddleware(cfg.Server.MaxDecompressedBodyBytes))
+
+	// 每個請求的處理期限，handler 以 Request.Context() 查詢 DB 時逾時會被取消
+	router.Use(middlewares.TimeoutMiddleware(cfg.Server.RequestTimeout))
 
 	// Swagger UI
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// API routes
-	apiRouter := router.Group("/api/v1")
+	// Health checks 與版本資訊（掛在根目錄、不需登入，給 load balancer / k8s probe 與 client 使用）
+	router.GET("/healthz", handlers.Healthz(database))
+	router.GET("/livez", handlers.Livez())
+	v2BasePath := apiVersionPath(cfg.Server.APIBasePath, "v2")
+	router.GET("/version", handlers.Version(map[string]string{
+		"v1": cfg.Server.APIBasePath,
+		"v2": v2BasePath,
+	}))
+
+	// API v1（前綴可由 API_BASE_PATH 設定，Swagger 等掛在根目錄的路由不受影響）
+	apiRouter, protected := registerAPIVersion(router, cfg.Server.APIBasePath, cfg)
 	
 	// Public routes (no auth required)
-	RegisterAuthRoutes(apiRouter, database, emailService)
+	RegisterAuthRoutes(apiRouter, database, emailService, cfg, userCache)
 
 	// Protected routes (JWT auth required)
-	protected := apiRouter.Group("")
-	protected.Use(middlewares.JWTAuthMiddleware())
 	{
-		RegisterProfileRoutes(protected)
-		RegisterPlanRoutes(protected, database)
+		RegisterProfileRoutes(protected, database, emailService, userCache)
+		RegisterPlanRoutes(protected, database, cfg)
+	}
+
+	// API v2（與 v1 共用 JWT 驗證，登入等公開端點仍使用 v1）
+	_, protectedV2 := registerAPIVersion(router, v2BasePath, cfg)
+	RegisterV2Routes(protectedV2, database)
+}
+
+// registerAPIVersion 建立某個 API 版本的路由群組，回傳公開與需要 JWT 的群組，兩者套用相同的中介層
+func registerAPIVersion(router *gin.Engine, basePath string, cfg *config.Config) (*gin.RouterGroup, *gin.RouterGroup) {
+	public := router.Group(basePath)
+	// 預設一律 no-store，需登入的 GET 端點再依 CACHE_MAX_AGE 覆寫
+	public.Use(middlewares.CacheControlMiddleware(0))
+	// 有內容的寫入請求一律要求 JSON
+	public.Use(middlewares.RequireJSONMiddleware())
+
+	protected := public.Group("")
+	protected.Use(middlewares.JWTAuthMiddleware(cfg))
+	protected.Use(middlewares.CacheControlMiddleware(cfg.Server.CacheMaxAge))
+	return public, protected
+}
+
+// apiVersionPath 把 API_BASE_PATH 結尾的 /v1 換成指定版本，例如 /todo/api/v1 → /todo/api/v2；
+// 沒有以 /v1 結尾時直接在後面加上版本
+func apiVersionPath(basePath string, version string) string {
+	if trimmed, found := strings.CutSuffix(basePath, "/v1"); found {
+		return trimmed + "/" + version
 	}
+	return basePath + "/" + version
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Walter1412/micro-backend/handlers"
+)
+
+// RegisterV2Routes 註冊 v2 的受保護路由。
+// 只有回應格式改變的端點才在這裡註冊新版 handler（例如 handlers.ProfileV2）；
+// 沒有改變的端點可以直接掛上 v1 的 handler，例如 router.GET("/plans/stats", handlers.GetPlanStats(database))。
+func RegisterV2Routes(router *gin.RouterGroup, database *sql.DB) {
+	router.GET("/profile", handlers.ProfileV2(database))
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/Walter1412/micro-backend/models"
+)
+
+// RunCleanup 每隔 interval 刪除過期或已使用的重設密碼 token，並永久刪除軟刪除超過 models.TaskTrashRetention 的任務，
+// 直到 ctx 結束；啟動時會先執行一次
+func RunCleanup(ctx context.Context, database *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	runCleanupOnce(ctx, database)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("✅ Cleanup stopped")
+			return
+		case <-ticker.C:
+			runCleanupOnce(ctx, database)
+		}
+	}
+}
+
+func runCleanupOnce(ctx context.Context, database *sql.DB) {
+	cleanupPasswordResets(ctx, database)
+	purgeDeletedTasks(ctx, database)
+}
+
+func cleanupPasswordResets(ctx context.Context, database *sql.DB) {
+	deleted, err := models.CleanupExpiredPasswordResetsContext(ctx, database)
+	if err != nil {
+		// 關閉時查詢被取消，不視為錯誤
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("❌ Failed to clean up password resets: %v", err)
+		return
+	}
+	log.Printf("✅ Cleaned up password resets: Deleted=%d", deleted)
+}
+
+func purgeDeletedTasks(ctx context.Context, database *sql.DB) {
+	deleted, err := models.PurgeDeletedTasksContext(ctx, database, models.TaskTrashRetention)
+	if err != nil {
+		// 關閉時查詢被取消，不視為錯誤
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("❌ Failed to purge deleted tasks: %v", err)
+		return
+	}
+	log.Printf("✅ Purged deleted tasks: Deleted=%d", deleted)
+}
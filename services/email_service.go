@@ -1,12 +1,32 @@
 package services
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
+	"html"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/Walter1412/micro-backend/config"
 )
 
+// EmailSender 為 handlers 寄信所需的介面，方便以假的實作替換 SMTP
+type EmailSender interface {
+	SendPasswordResetEmail(toEmail, token string) error
+	SendVerificationEmail(toEmail, token string) error
+	SendWelcomeEmail(toEmail, username string) error
+}
+
+var _ EmailSender = (*EmailService)(nil)
+
 type EmailService struct {
 	config config.EmailConfig
 }
@@ -17,15 +37,31 @@ func NewEmailService(cfg config.EmailConfig) *EmailService {
 	}
 }
 
+func (e *EmailService) resetURL(token string) string {
+	return e.linkURL("/reset-password", token)
+}
+
+func (e *EmailService) verifyURL(token string) string {
+	return e.linkURL("/verify-email", token)
+}
+
+func (e *EmailService) linkURL(path, token string) string {
+	base := strings.TrimRight(e.config.ResetURLBase, "/")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return fmt.Sprintf("%s%s?token=%s", base, path, url.QueryEscape(token))
+}
+
 func (e *EmailService) SendPasswordResetEmail(toEmail, token string) error {
 	if e.config.SMTPHost == "" || e.config.SMTPUsername == "" {
 		// 開發模式：只是記錄 token，不真的發送郵件
 		fmt.Printf("🔧 [DEV MODE] Password reset token for %s: %s\n", toEmail, token)
-		fmt.Printf("🔧 [DEV MODE] Reset URL: http://localhost:3000/reset-password?token=%s\n", token)
+		fmt.Printf("🔧 [DEV MODE] Reset URL: %s\n", e.resetURL(token))
 		return nil // 開發環境下不返回錯誤
 	}
 
-	resetURL := fmt.Sprintf("http://localhost:3000/reset-password?token=%s", token)
+	resetURL := e.resetURL(token)
 	
 	subject := "Password Reset Request"
 	body := fmt.Sprintf(`
@@ -43,24 +79,59 @@ Best regards,
 Your App Team
 `, resetURL)
 
-	message := fmt.Sprintf("Subject: %s\r\n\r\n%s", subject, body)
+	htmlBody := fmt.Sprintf(`<p>Dear User,</p>
+<p>You have requested to reset your password. Please click the link below to reset your password:</p>
+<p><a href="%[1]s">%[1]s</a></p>
+<p>This link will expire in 1 hour.</p>
+<p>If you did not request this password reset, please ignore this email.</p>
+<p>Best regards,<br>Your App Team</p>
+`, html.EscapeString(resetURL))
 
-	auth := smtp.PlainAuth("", e.config.SMTPUsername, e.config.SMTPPassword, e.config.SMTPHost)
-	
-	err := smtp.SendMail(
-		e.config.SMTPHost+":"+e.config.SMTPPort,
-		auth,
-		e.config.FromEmail,
-		[]string{toEmail},
-		[]byte(message),
-	)
-
-	return err
+	return e.send(toEmail, subject, body, htmlBody)
+}
+
+func (e *EmailService) SendVerificationEmail(toEmail, token string) error {
+	if e.config.SMTPHost == "" || e.config.SMTPUsername == "" {
+		// 開發模式：只是記錄 token，不真的發送郵件
+		fmt.Printf("🔧 [DEV MODE] Email verification token for %s: %s\n", toEmail, token)
+		fmt.Printf("🔧 [DEV MODE] Verify URL: %s\n", e.verifyURL(token))
+		return nil // 開發環境下不返回錯誤
+	}
+
+	verifyURL := e.verifyURL(token)
+
+	subject := "Please Verify Your Email"
+	body := fmt.Sprintf(`
+Dear User,
+
+Thanks for signing up! Please confirm your email address by clicking the link below:
+
+%s
+
+This link will expire in 24 hours.
+
+If you did not create an account, please ignore this email.
+
+Best regards,
+Your App Team
+`, verifyURL)
+
+	htmlBody := fmt.Sprintf(`<p>Dear User,</p>
+<p>Thanks for signing up! Please confirm your email address by clicking the link below:</p>
+<p><a href="%[1]s">%[1]s</a></p>
+<p>This link will expire in 24 hours.</p>
+<p>If you did not create an account, please ignore this email.</p>
+<p>Best regards,<br>Your App Team</p>
+`, html.EscapeString(verifyURL))
+
+	return e.send(toEmail, subject, body, htmlBody)
 }
 
 func (e *EmailService) SendWelcomeEmail(toEmail, username string) error {
 	if e.config.SMTPHost == "" || e.config.SMTPUsername == "" {
-		return fmt.Errorf("email configuration not set")
+		// 開發模式：只記錄，不真的發送郵件
+		fmt.Printf("🔧 [DEV MODE] Welcome email for %s (%s)\n", toEmail, username)
+		return nil // 開發環境下不返回錯誤
 	}
 	
 	subject := "Welcome to Our Platform"
@@ -75,17 +146,137 @@ Best regards,
 Your App Team
 `, username)
 
-	message := fmt.Sprintf("Subject: %s\r\n\r\n%s", subject, body)
+	htmlBody := fmt.Sprintf(`<p>Dear %s,</p>
+<p>Welcome to our platform! Your account has been successfully created.</p>
+<p>If you have any questions, feel free to contact our support team.</p>
+<p>Best regards,<br>Your App Team</p>
+`, html.EscapeString(username))
+
+	return e.send(toEmail, subject, body, htmlBody)
+}
 
+const (
+	sendMaxAttempts = 3
+	sendRetryDelay  = 500 * time.Millisecond
+)
+
+// send 以 multipart/alternative 組出完整信件並透過 SMTP 寄出，失敗時以指數退避重試
+func (e *EmailService) send(toEmail, subject, textBody, htmlBody string) error {
+	message, err := e.buildMessage(toEmail, subject, textBody, htmlBody)
+	if err != nil {
+		return err
+	}
+
+	delay := sendRetryDelay
+	for attempt := 1; ; attempt++ {
+		err = e.deliver(toEmail, message)
+		if err == nil {
+			return nil
+		}
+		if attempt >= sendMaxAttempts {
+			return fmt.Errorf("send email failed after %d attempts: %w", attempt, err)
+		}
+		log.Printf("⚠️ Send email attempt %d/%d failed: %v", attempt, sendMaxAttempts, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (e *EmailService) deliver(toEmail string, message []byte) error {
 	auth := smtp.PlainAuth("", e.config.SMTPUsername, e.config.SMTPPassword, e.config.SMTPHost)
-	
-	err := smtp.SendMail(
-		e.config.SMTPHost+":"+e.config.SMTPPort,
-		auth,
-		e.config.FromEmail,
-		[]string{toEmail},
-		[]byte(message),
-	)
-
-	return err
-}
\ No newline at end of file
+	address := e.config.SMTPHost + ":" + e.config.SMTPPort
+
+	if !e.config.UseTLS {
+		return smtp.SendMail(address, auth, e.config.FromEmail, []string{toEmail}, message)
+	}
+
+	client, err := smtp.Dial(address)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// 先 STARTTLS 再認證，避免帳密以明文傳送
+	tlsConfig := &tls.Config{
+		ServerName:         e.config.SMTPHost,
+		InsecureSkipVerify: e.config.InsecureSkipVerify,
+	}
+	if err := client.StartTLS(tlsConfig); err != nil {
+		return fmt.Errorf("starttls: %w", err)
+	}
+	if err := client.Auth(auth); err != nil {
+		return err
+	}
+	if err := client.Mail(e.config.FromEmail); err != nil {
+		return err
+	}
+	if err := client.Rcpt(toEmail); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// fromHeader 依 FromName / FromEmail 組出 "Name <email>" 格式的寄件者
+func (e *EmailService) fromHeader() string {
+	from := mail.Address{Name: e.config.FromName, Address: e.config.FromEmail}
+	return from.String()
+}
+
+// buildMessage 產生符合 RFC 5322 的信件，內容同時帶純文字與 HTML 版本
+func (e *EmailService) buildMessage(toEmail, subject, textBody, htmlBody string) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", textBody},
+		{"text/html; charset=UTF-8", htmlBody},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "8bit")
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := partWriter.Write([]byte(strings.ReplaceAll(part.content, "\n", "\r\n"))); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	headers := []struct{ key, value string }{
+		{"From", e.fromHeader()},
+		{"To", (&mail.Address{Address: toEmail}).String()},
+		{"Subject", mime.QEncoding.Encode("UTF-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary())},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header.key, header.value)
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	"github.com/Walter1412/micro-backend/config"
+)
+
+func TestResetURLUsesCustomBaseURL(t *testing.T) {
+	service := NewEmailService(config.EmailConfig{ResetURLBase: "https://app.example.com/"})
+
+	want := "https://app.example.com/reset-password?token=abc%2B123"
+	if got := service.resetURL("abc+123"); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestResetURLDefaultsToLocalhost(t *testing.T) {
+	service := NewEmailService(config.EmailConfig{})
+
+	want := "http://localhost:3000/reset-password?token=abc"
+	if got := service.resetURL("abc"); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestBuildMessageHeaders(t *testing.T) {
+	service := NewEmailService(config.EmailConfig{FromEmail: "noreply@example.com", FromName: "Micro App"})
+
+	raw, err := service.buildMessage("user@example.com", "Password Reset Request", "plain body\n", "<p>html body</p>\n")
+	if err != nil {
+		t.Fatalf("buildMessage: %v", err)
+	}
+	message, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("generated message is not RFC 5322: %v", err)
+	}
+
+	from, err := mail.ParseAddress(message.Header.Get("From"))
+	if err != nil {
+		t.Fatalf("invalid From header %q: %v", message.Header.Get("From"), err)
+	}
+	if from.Name != "Micro App" || from.Address != "noreply@example.com" {
+		t.Fatalf("unexpected From: %+v", from)
+	}
+	if to := message.Header.Get("To"); to != "<user@example.com>" {
+		t.Fatalf("unexpected To: %q", to)
+	}
+	if message.Header.Get("MIME-Version") != "1.0" {
+		t.Fatalf("unexpected MIME-Version: %q", message.Header.Get("MIME-Version"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("invalid Content-Type: %v", err)
+	}
+	if mediaType != "multipart/alternative" || params["boundary"] == "" {
+		t.Fatalf("expected multipart/alternative with a boundary, got %q %v", mediaType, params)
+	}
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	var contentTypes []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid multipart body: %v", err)
+		}
+		contentTypes = append(contentTypes, part.Header.Get("Content-Type"))
+	}
+	want := []string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8"}
+	if len(contentTypes) != len(want) || contentTypes[0] != want[0] || contentTypes[1] != want[1] {
+		t.Fatalf("expected parts %v, got %v", want, contentTypes)
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+type loginFailures struct {
+	count        int
+	firstFailure time.Time
+}
+
+// LoginLimiter 以 email 為單位記錄連續登入失敗次數，
+// 在 window 期間內失敗達 maxFailures 次即暫停該帳號登入，直到 window 結束。
+type LoginLimiter struct {
+	mutex       sync.Mutex
+	failures    map[string]*loginFailures
+	maxFailures int
+	window      time.Duration
+	lastSweep   time.Time
+}
+
+func NewLoginLimiter(maxFailures int, window time.Duration) *LoginLimiter {
+	return &LoginLimiter{
+		failures:    make(map[string]*loginFailures),
+		maxFailures: maxFailures,
+		window:      window,
+		lastSweep:   time.Now(),
+	}
+}
+
+// RetryAfter 回傳帳號是否被暫停，以及需要等待的時間
+func (l *LoginLimiter) RetryAfter(email string) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry, exists := l.failures[normalizeEmail(email)]
+	if !exists || entry.count < l.maxFailures {
+		return 0, false
+	}
+	remaining := time.Until(entry.firstFailure.Add(l.window))
+	if remaining <= 0 {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// RecordFailure 記錄一次失敗；超過 window 的舊紀錄會重新計算
+func (l *LoginLimiter) RecordFailure(email string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	key := normalizeEmail(email)
+	entry, exists := l.failures[key]
+	if !exists || now.Sub(entry.firstFailure) > l.window {
+		l.failures[key] = &loginFailures{count: 1, firstFailure: now}
+		return
+	}
+	entry.count++
+}
+
+// Reset 在登入成功後清除該帳號的失敗紀錄
+func (l *LoginLimiter) Reset(email string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.failures, normalizeEmail(email))
+}
+
+// sweep 每個 window 清一次過期紀錄，避免 map 無限成長（呼叫端需持有 mutex）
+func (l *LoginLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	for key, entry := range l.failures {
+		if now.Sub(entry.firstFailure) > l.window {
+			delete(l.failures, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
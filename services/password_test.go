@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPasswordUsesConfiguredCost(t *testing.T) {
+	const cost = bcrypt.MinCost + 1
+
+	hashed, err := HashPassword("correct horse", cost)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte("correct horse")); err != nil {
+		t.Fatalf("expected hash to verify, got %v", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte("wrong horse")); err == nil {
+		t.Fatal("expected wrong password to be rejected")
+	}
+
+	got, err := bcrypt.Cost([]byte(hashed))
+	if err != nil {
+		t.Fatalf("bcrypt.Cost: %v", err)
+	}
+	if got != cost {
+		t.Fatalf("expected cost %d, got %d", cost, got)
+	}
+}